@@ -12,6 +12,7 @@ import (
 	"gonext/internal/game"
 	"gonext/internal/live"
 	"gonext/internal/mail"
+	"gonext/internal/match"
 	"gonext/internal/mdw"
 	"gonext/internal/repo"
 	"gonext/internal/token"
@@ -69,6 +70,9 @@ func main() {
 
 	gameRegistry := game.NewRegistry()
 	gameRegistry.RegisterAll()
+	if err := gameRegistry.LoadRules(appCfg.GamesDir); err != nil {
+		panic(err)
+	}
 
 	r.Route("/api", func(api chi.Router) {
 		api.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -83,7 +87,9 @@ func main() {
 
 		api.Group(func(protected chi.Router) {
 			protected.Use(authMdw)
-			protected.Mount("/live", live.Router(gameRegistry, appCfg.WS))
+			protected.Mount("/live", live.Router(gameRegistry, store.Game, store.Rating, store.KVStore, store.Bus, appCfg.WS))
+			protected.Mount("/games", match.Router(gameRegistry, store.Game, store.Rating))
+			protected.Mount("/ratings", match.RatingRouter(store.Rating))
 		})
 	})
 
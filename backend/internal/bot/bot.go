@@ -0,0 +1,89 @@
+// Package bot plays games against people. Bots only see the public game
+// state and submit their moves through Game.Move like anyone else.
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+
+	"gonext/internal/game"
+)
+
+// Name is the player name bots sit down under. The leading underscore keeps
+// it apart from usernames, as with "_server".
+const Name = "_bot"
+
+const (
+	Easy   = "easy"
+	Medium = "medium"
+	Hard   = "hard"
+)
+
+type level struct {
+	index   int     // into an engine's depths
+	blunder float64 // chance of playing a random move instead
+}
+
+var levels = map[string]level{
+	Easy:   {index: 0, blunder: 0.3},
+	Medium: {index: 1, blunder: 0.1},
+	Hard:   {index: 2, blunder: 0},
+}
+
+type engine interface {
+	legalMoves(state *game.GameState, seat int) ([]game.GameMove, error)
+	bestMove(state *game.GameState, seat, depth int) (*game.GameMove, error)
+}
+
+type Bot struct {
+	engine     engine
+	depth      int
+	level      level
+	difficulty string
+}
+
+func New(gameName, difficulty string) (*Bot, error) {
+	lvl, ok := levels[difficulty]
+	if !ok {
+		return nil, fmt.Errorf("unknown difficulty: %s", difficulty)
+	}
+	var eng engine
+	var depths [3]int
+	switch gameName {
+	case "tictactoe":
+		eng, depths = ticTacToe{}, [3]int{9, 9, 9}
+	case "connect4":
+		eng, depths = connect4{}, [3]int{2, 4, 7}
+	case "chess":
+		eng, depths = chessEngine{}, [3]int{1, 2, 3}
+	default:
+		return nil, fmt.Errorf("no bot for %s", gameName)
+	}
+	return &Bot{engine: eng, depth: depths[lvl.index], level: lvl, difficulty: difficulty}, nil
+}
+
+func (b *Bot) Difficulty() string {
+	return b.difficulty
+}
+
+// Think picks the bot's move in state. It is an error to ask when it is not
+// the bot's turn.
+func (b *Bot) Think(state *game.GameState) (*game.GameMove, error) {
+	seat := slices.Index(state.Players, Name)
+	if seat == -1 || seat != state.Turn || state.Status != game.StatusInProgress {
+		return nil, errors.New("not the bot's turn")
+	}
+	if rand.Float64() < b.level.blunder {
+		moves, err := b.engine.legalMoves(state, seat)
+		if err != nil {
+			return nil, err
+		}
+		if len(moves) == 0 {
+			return nil, errors.New("no legal moves")
+		}
+		return &moves[rand.IntN(len(moves))], nil
+	}
+	return b.engine.bestMove(state, seat, b.depth)
+}
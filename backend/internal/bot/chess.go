@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"errors"
+	"slices"
+
+	"gonext/internal/game"
+
+	"github.com/corentings/chess/v2"
+)
+
+const chessMate = 1_000_000
+
+type chessEngine struct{}
+
+var pieceValues = map[chess.PieceType]int{
+	chess.Pawn:   100,
+	chess.Knight: 320,
+	chess.Bishop: 330,
+	chess.Rook:   500,
+	chess.Queen:  900,
+}
+
+var promoCodes = map[chess.PieceType]string{
+	chess.Queen:  "q",
+	chess.Rook:   "r",
+	chess.Bishop: "b",
+	chess.Knight: "n",
+}
+
+func chessPosition(state *game.GameState) (*chess.Position, error) {
+	if state.Notation == nil || state.Notation.FEN == "" {
+		return nil, errors.New("chess state has no FEN")
+	}
+	opt, err := chess.FEN(state.Notation.FEN)
+	if err != nil {
+		return nil, err
+	}
+	return chess.NewGame(opt).Position(), nil
+}
+
+func toGameMove(m *chess.Move) game.GameMove {
+	from, to := m.S1(), m.S2()
+	return game.GameMove{
+		From:   game.Position{Row: 7 - int(from.Rank()), Col: int(from.File())},
+		To:     game.Position{Row: 7 - int(to.Rank()), Col: int(to.File())},
+		Change: promoCodes[m.Promo()],
+	}
+}
+
+func (chessEngine) legalMoves(state *game.GameState, _ int) ([]game.GameMove, error) {
+	pos, err := chessPosition(state)
+	if err != nil {
+		return nil, err
+	}
+	moves := []game.GameMove{}
+	for _, m := range pos.ValidMoves() {
+		moves = append(moves, toGameMove(&m))
+	}
+	return moves, nil
+}
+
+func (chessEngine) bestMove(state *game.GameState, _, depth int) (*game.GameMove, error) {
+	pos, err := chessPosition(state)
+	if err != nil {
+		return nil, err
+	}
+	moves := orderedMoves(pos)
+	if len(moves) == 0 {
+		return nil, errors.New("no legal moves")
+	}
+	best := moves[0]
+	alpha := -chessMate * 2
+	for _, m := range moves {
+		score := -chessNegamax(pos.Update(&m), depth-1, -chessMate*2, -alpha)
+		if score > alpha {
+			alpha, best = score, m
+		}
+	}
+	mv := toGameMove(&best)
+	return &mv, nil
+}
+
+func chessNegamax(pos *chess.Position, depth, alpha, beta int) int {
+	moves := orderedMoves(pos)
+	if len(moves) == 0 {
+		if pos.Status() == chess.Checkmate {
+			return -chessMate - depth // prefer faster mates
+		}
+		return 0
+	}
+	if depth <= 0 {
+		return chessEvaluate(pos)
+	}
+	for _, m := range moves {
+		score := -chessNegamax(pos.Update(&m), depth-1, -beta, -alpha)
+		if score > alpha {
+			alpha = score
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+	return alpha
+}
+
+// orderedMoves puts promotions and captures first so cutoffs come early.
+func orderedMoves(pos *chess.Position) []chess.Move {
+	moves := pos.ValidMoves()
+	rank := func(m *chess.Move) int {
+		r := pieceValues[m.Promo()]
+		if m.HasTag(chess.Capture) {
+			r += pieceValues[pos.Board().Piece(m.S2()).Type()] + 1
+		}
+		return r
+	}
+	slices.SortStableFunc(moves, func(a, b chess.Move) int {
+		return rank(&b) - rank(&a)
+	})
+	return moves
+}
+
+// chessEvaluate is material plus a little for central, advanced pieces,
+// from the side to move's point of view.
+func chessEvaluate(pos *chess.Position) int {
+	score := 0
+	for sq, piece := range pos.Board().SquareMap() {
+		value := pieceValues[piece.Type()]
+		file, rank := int(sq.File()), int(sq.Rank())
+		switch piece.Type() {
+		case chess.Knight, chess.Bishop:
+			value += 10 - 3*(centreDistance(file)+centreDistance(rank))
+		case chess.Pawn:
+			advance := rank - 1
+			if piece.Color() == chess.Black {
+				advance = 6 - rank
+			}
+			value += 5*advance - 3*centreDistance(file)
+		}
+		if piece.Color() == pos.Turn() {
+			score += value
+		} else {
+			score -= value
+		}
+	}
+	return score
+}
+
+// centreDistance is how far a file or rank index is from the middle two.
+func centreDistance(i int) int {
+	if i < 4 {
+		return 3 - i
+	}
+	return i - 4
+}
@@ -0,0 +1,159 @@
+package bot
+
+import (
+	"errors"
+
+	"gonext/internal/game"
+)
+
+const (
+	c4Rows = 6
+	c4Cols = 7
+	c4Win  = 1_000_000
+)
+
+type connect4 struct{}
+
+// columns are searched centre first, which lets alpha-beta prune sooner.
+var c4Order = [c4Cols]int{3, 2, 4, 1, 5, 0, 6}
+
+func c4Board(state *game.GameState) ([c4Rows][c4Cols]int, error) {
+	board, ok := state.Board.([c4Rows][c4Cols]int)
+	if !ok {
+		return board, errors.New("unexpected connect4 board")
+	}
+	return board, nil
+}
+
+// c4Drop is the row a disc dropped in col lands on, or -1 if it is full.
+func c4Drop(board *[c4Rows][c4Cols]int, col int) int {
+	for row := c4Rows - 1; row >= 0; row-- {
+		if board[row][col] == 0 {
+			return row
+		}
+	}
+	return -1
+}
+
+func (connect4) legalMoves(state *game.GameState, _ int) ([]game.GameMove, error) {
+	board, err := c4Board(state)
+	if err != nil {
+		return nil, err
+	}
+	moves := []game.GameMove{}
+	for col := range c4Cols {
+		if row := c4Drop(&board, col); row != -1 {
+			moves = append(moves, game.GameMove{To: game.Position{Row: row, Col: col}})
+		}
+	}
+	return moves, nil
+}
+
+func (connect4) bestMove(state *game.GameState, seat, depth int) (*game.GameMove, error) {
+	board, err := c4Board(state)
+	if err != nil {
+		return nil, err
+	}
+	me := seat + 1
+	var best *game.GameMove
+	alpha := -c4Win * 2
+	for _, col := range c4Order {
+		row := c4Drop(&board, col)
+		if row == -1 {
+			continue
+		}
+		board[row][col] = me
+		score := -c4Negamax(&board, row, col, 3-me, depth-1, -c4Win*2, -alpha)
+		board[row][col] = 0
+		if best == nil || score > alpha {
+			alpha = score
+			best = &game.GameMove{To: game.Position{Row: row, Col: col}}
+		}
+	}
+	if best == nil {
+		return nil, errors.New("no legal moves")
+	}
+	return best, nil
+}
+
+// c4Negamax scores the board for toMove, given that the other side just
+// dropped a disc at (row, col).
+func c4Negamax(board *[c4Rows][c4Cols]int, row, col, toMove, depth, alpha, beta int) int {
+	if c4Connects(board, row, col) {
+		return -c4Win - depth // prefer faster wins
+	}
+	if depth == 0 {
+		return c4Evaluate(board, toMove)
+	}
+	moved := false
+	for _, c := range c4Order {
+		r := c4Drop(board, c)
+		if r == -1 {
+			continue
+		}
+		moved = true
+		board[r][c] = toMove
+		score := -c4Negamax(board, r, c, 3-toMove, depth-1, -beta, -alpha)
+		board[r][c] = 0
+		if score > alpha {
+			alpha = score
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+	if !moved {
+		return 0
+	}
+	return alpha
+}
+
+func c4Connects(board *[c4Rows][c4Cols]int, row, col int) bool {
+	player := board[row][col]
+	for _, d := range [4][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}} {
+		count := 1
+		for _, sign := range [2]int{1, -1} {
+			r, c := row+sign*d[0], col+sign*d[1]
+			for r >= 0 && r < c4Rows && c >= 0 && c < c4Cols && board[r][c] == player {
+				count++
+				r, c = r+sign*d[0], c+sign*d[1]
+			}
+		}
+		if count >= 4 {
+			return true
+		}
+	}
+	return false
+}
+
+// c4Evaluate scores every window of four cells that only one side occupies.
+func c4Evaluate(board *[c4Rows][c4Cols]int, player int) int {
+	weights := [5]int{0, 1, 5, 50, 1000}
+	score := 0
+	for row := range c4Rows {
+		for col := range c4Cols {
+			for _, d := range [4][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}} {
+				endRow, endCol := row+3*d[0], col+3*d[1]
+				if endRow >= c4Rows || endCol < 0 || endCol >= c4Cols {
+					continue
+				}
+				mine, theirs := 0, 0
+				for i := range 4 {
+					switch board[row+i*d[0]][col+i*d[1]] {
+					case 0:
+					case player:
+						mine++
+					default:
+						theirs++
+					}
+				}
+				if theirs == 0 {
+					score += weights[mine]
+				} else if mine == 0 {
+					score -= weights[theirs]
+				}
+			}
+		}
+	}
+	return score
+}
@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"errors"
+	"math/rand/v2"
+
+	"gonext/internal/game"
+)
+
+type ticTacToe struct{}
+
+var tttLines = [8][3][2]int{
+	{{0, 0}, {0, 1}, {0, 2}},
+	{{1, 0}, {1, 1}, {1, 2}},
+	{{2, 0}, {2, 1}, {2, 2}},
+	{{0, 0}, {1, 0}, {2, 0}},
+	{{0, 1}, {1, 1}, {2, 1}},
+	{{0, 2}, {1, 2}, {2, 2}},
+	{{0, 0}, {1, 1}, {2, 2}},
+	{{0, 2}, {1, 1}, {2, 0}},
+}
+
+func tttBoard(state *game.GameState) ([3][3]int, error) {
+	board, ok := state.Board.([3][3]int)
+	if !ok {
+		return board, errors.New("unexpected tictactoe board")
+	}
+	return board, nil
+}
+
+func (ticTacToe) legalMoves(state *game.GameState, _ int) ([]game.GameMove, error) {
+	board, err := tttBoard(state)
+	if err != nil {
+		return nil, err
+	}
+	return tttMoves(&board), nil
+}
+
+func tttMoves(board *[3][3]int) []game.GameMove {
+	moves := []game.GameMove{}
+	for row := range 3 {
+		for col := range 3 {
+			if board[row][col] == 0 {
+				moves = append(moves, game.GameMove{To: game.Position{Row: row, Col: col}})
+			}
+		}
+	}
+	return moves
+}
+
+// bestMove searches the whole tree; ties are broken at random so the bot
+// does not always open the same way.
+func (ticTacToe) bestMove(state *game.GameState, seat, _ int) (*game.GameMove, error) {
+	board, err := tttBoard(state)
+	if err != nil {
+		return nil, err
+	}
+	me := seat + 1
+	var best []game.GameMove
+	bestScore := -100
+	for _, mv := range tttMoves(&board) {
+		board[mv.To.Row][mv.To.Col] = me
+		score := -tttNegamax(&board, 3-me, 1)
+		board[mv.To.Row][mv.To.Col] = 0
+		if score > bestScore {
+			bestScore, best = score, nil
+		}
+		if score == bestScore {
+			best = append(best, mv)
+		}
+	}
+	if len(best) == 0 {
+		return nil, errors.New("no legal moves")
+	}
+	return &best[rand.IntN(len(best))], nil
+}
+
+// tttNegamax scores the board for toMove: quicker wins and slower losses
+// score higher.
+func tttNegamax(board *[3][3]int, toMove, ply int) int {
+	if tttWinner(board) != 0 {
+		return ply - 10 // the previous mover just won
+	}
+	moves := tttMoves(board)
+	if len(moves) == 0 {
+		return 0
+	}
+	best := -100
+	for _, mv := range moves {
+		board[mv.To.Row][mv.To.Col] = toMove
+		best = max(best, -tttNegamax(board, 3-toMove, ply+1))
+		board[mv.To.Row][mv.To.Col] = 0
+	}
+	return best
+}
+
+func tttWinner(board *[3][3]int) int {
+	for _, line := range tttLines {
+		a, b, c := line[0], line[1], line[2]
+		if v := board[a[0]][a[1]]; v != 0 && v == board[b[0]][b[1]] && v == board[c[0]][c[1]] {
+			return v
+		}
+	}
+	return 0
+}
@@ -40,6 +40,29 @@ type WS struct {
 	MsgBuffer      int64
 	SendBuffer     int64
 	RecvBuffer     int64
+
+	SnapshotKey   string // running games, by room
+	SnapshotIndex string // rooms with a snapshot
+	SnapshotTTL   time.Duration
+
+	ClusterTTL  time.Duration // how long an instance's claims outlive it
+	OwnerKey    string        // instance running each room
+	NodeKey     string        // instances still alive
+	NodeChannel string        // where each instance gets its messages
+	RoomInfoKey string        // what the list of rooms shows of each
+	RoomIndex   string        // open rooms
+
+	SessionKey  string        // resume token sessions
+	SessionTTL  time.Duration // of a session while its client is connected
+	ResumeGrace time.Duration // to come back after losing the connection
+	ResumeLog   int           // messages each room keeps for clients coming back
+
+	InviteSecret string // signs room invites
+	InviteTTL    time.Duration
+	BanKey       string // users banned from a room
+	BanTTL       time.Duration
+
+	CallSize int // of a room's video call, where everyone connects to everyone
 }
 
 type Mail struct {
@@ -56,6 +79,7 @@ type AppConfig struct {
 	// Port        string
 	// FrontendUrl string
 	StaticPages string
+	GamesDir    string // rule files for games defined without code
 	Auth        *Auth
 	DB          *DB
 	WS          *WS
@@ -68,6 +92,7 @@ func Load() (*AppConfig, error) {
 		// Port:        os.Getenv("GO_PORT"),
 		// FrontendUrl: os.Getenv("FRONTEND"),
 		StaticPages: "/app/static",
+		GamesDir:    "/app/games",
 		Mail: &Mail{
 			MailKey:  os.Getenv("RESEND_KEY"),
 			MailFrom: os.Getenv("MAIL_FROM"),
@@ -104,6 +129,29 @@ func Load() (*AppConfig, error) {
 			MsgBuffer:      256,
 			SendBuffer:     64,
 			RecvBuffer:     64,
+
+			SnapshotKey:   "roomSnap:%v",
+			SnapshotIndex: "roomSnaps",
+			SnapshotTTL:   time.Hour,
+
+			ClusterTTL:  15 * time.Second,
+			OwnerKey:    "roomOwner:%v",
+			NodeKey:     "node:%v",
+			NodeChannel: "live:%v",
+			RoomInfoKey: "roomInfo:%v",
+			RoomIndex:   "rooms",
+
+			SessionKey:  "wsSession:%v",
+			SessionTTL:  24 * time.Hour,
+			ResumeGrace: 30 * time.Second,
+			ResumeLog:   48, // stays under SendBuffer
+
+			InviteSecret: os.Getenv("JWT_INVITE_SECRET"),
+			InviteTTL:    24 * time.Hour,
+			BanKey:       "roomBans:%v",
+			BanTTL:       30 * 24 * time.Hour,
+
+			CallSize: 8,
 		},
 		Token: &Token{
 			RefTTL:         24 * time.Hour,
@@ -0,0 +1,189 @@
+package game
+
+import (
+	"errors"
+	"slices"
+)
+
+const battleshipSize = 10
+
+// Cells of a battleship board as a player sees them.
+const (
+	bsWater = iota // or not known
+	bsShip
+	bsMiss
+	bsHit
+	bsSunk
+)
+
+// battleshipFleet is the length of each ship, in the order they are placed.
+var battleshipFleet = []int{5, 4, 3, 3, 2}
+
+// battleship has each player place their fleet in secret, seat 0 first, one
+// ship per move: To is the ship's top or left end and Change is "h" or "v".
+// Then they take turns firing at each other's waters with To. Players only
+// see their own ships until the game is over.
+type battleship struct {
+	baseGame
+	ships [2][]warship
+	shots [2][battleshipSize][battleshipSize]bool // shots fired into each seat's waters
+}
+
+type warship struct {
+	cells []Position
+	hits  int
+}
+
+func newBattleship() Factory {
+	return func(opts *Options, updator func(GameUpdate)) (Game, error) {
+		game := &battleship{baseGame: newBase(2, "battleship", opts, updator)}
+		game.self = game
+		return game, nil
+	}
+}
+
+// getBoardLocked shows both fleets; players get theirs from boardForLocked.
+func (b *battleship) getBoardLocked() any {
+	return b.boardsLocked(func(int) bool { return true })
+}
+
+func (b *battleship) boardForLocked(viewer string) any {
+	seat := slices.Index(b.players, viewer)
+	return b.boardsLocked(func(s int) bool { return s == seat || b.status == StatusFin })
+}
+
+// boardsLocked returns both seats' waters, showing the ships of those seats
+// that reveal allows.
+func (b *battleship) boardsLocked(reveal func(seat int) bool) [2][battleshipSize][battleshipSize]int {
+	var boards [2][battleshipSize][battleshipSize]int
+	for seat := range boards {
+		for _, ship := range b.ships[seat] {
+			for _, p := range ship.cells {
+				switch {
+				case ship.hits == len(ship.cells):
+					boards[seat][p.Row][p.Col] = bsSunk
+				case b.shots[seat][p.Row][p.Col]:
+					boards[seat][p.Row][p.Col] = bsHit
+				case reveal(seat):
+					boards[seat][p.Row][p.Col] = bsShip
+				}
+			}
+		}
+		for row := range battleshipSize {
+			for col := range battleshipSize {
+				if b.shots[seat][row][col] && boards[seat][row][col] == bsWater {
+					boards[seat][row][col] = bsMiss
+				}
+			}
+		}
+	}
+	return boards
+}
+
+func (b *battleship) getValidMovesLocked() []GameMove {
+	validMoves := []GameMove{}
+	if b.status != StatusInProgress {
+		return validMoves
+	}
+	for row := range battleshipSize {
+		for col := range battleshipSize {
+			at := Position{Row: row, Col: col}
+			if b.placingLocked(b.turn) {
+				for _, dir := range []string{"h", "v"} {
+					if _, err := b.placeCellsLocked(b.turn, at, dir); err == nil {
+						validMoves = append(validMoves, GameMove{To: at, Change: dir})
+					}
+				}
+			} else if !b.shots[1-b.turn][row][col] {
+				validMoves = append(validMoves, GameMove{To: at})
+			}
+		}
+	}
+	return validMoves
+}
+
+func (b *battleship) Move(sender string, mv *GameMove) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	idx, err := b.checkTurnLocked(sender)
+	if err != nil {
+		return err
+	}
+
+	if b.placingLocked(idx) {
+		cells, err := b.placeCellsLocked(idx, mv.To, mv.Change)
+		if err != nil {
+			return err
+		}
+		b.ships[idx] = append(b.ships[idx], warship{cells: cells})
+		next := idx
+		if !b.placingLocked(idx) {
+			next = 1 - idx
+		}
+		b.endTurnToLocked(mv, next)
+		b.notifyLocked(UpdateAction)
+		return nil
+	}
+
+	target := 1 - idx
+	if mv.Change != "" || !onBattleshipBoard(mv.To) {
+		return errors.New("invalid move")
+	}
+	if b.shots[target][mv.To.Row][mv.To.Col] {
+		return errors.New("already fired there")
+	}
+	b.shots[target][mv.To.Row][mv.To.Col] = true
+	sunk := 0
+	for i := range b.ships[target] {
+		ship := &b.ships[target][i]
+		if slices.Contains(ship.cells, mv.To) {
+			ship.hits++
+		}
+		if ship.hits == len(ship.cells) {
+			sunk++
+		}
+	}
+	if sunk == len(battleshipFleet) {
+		b.finishLocked(sender, ReasonFleetSunk)
+	}
+	b.endTurnLocked(mv)
+	b.notifyLocked(UpdateAction)
+	return nil
+}
+
+func (b *battleship) placingLocked(seat int) bool {
+	return len(b.ships[seat]) < len(battleshipFleet)
+}
+
+// placeCellsLocked returns the cells seat's next ship would cover from at in
+// direction dir, checking that it stays in the water and clear of the rest
+// of the fleet.
+func (b *battleship) placeCellsLocked(seat int, at Position, dir string) ([]Position, error) {
+	var step Position
+	switch dir {
+	case "h":
+		step = Position{Col: 1}
+	case "v":
+		step = Position{Row: 1}
+	default:
+		return nil, errors.New("place a ship with change \"h\" or \"v\"")
+	}
+	cells := make([]Position, battleshipFleet[len(b.ships[seat])])
+	for i := range cells {
+		cells[i] = Position{Row: at.Row + i*step.Row, Col: at.Col + i*step.Col}
+		if !onBattleshipBoard(cells[i]) {
+			return nil, errors.New("ship does not fit there")
+		}
+		for _, ship := range b.ships[seat] {
+			if slices.Contains(ship.cells, cells[i]) {
+				return nil, errors.New("ships cannot overlap")
+			}
+		}
+	}
+	return cells, nil
+}
+
+func onBattleshipBoard(p Position) bool {
+	return p.Row >= 0 && p.Row < battleshipSize && p.Col >= 0 && p.Col < battleshipSize
+}
@@ -0,0 +1,261 @@
+package game
+
+import (
+	"errors"
+	"slices"
+)
+
+const (
+	checkersSize      = 8
+	checkersKing      = 10 // added to a man's code once crowned
+	checkersQuietPlys = 80 // 40 moves each without a capture or a man moving
+)
+
+// checkers is American checkers. Seat 0 starts at the bottom (rows 5-7) and
+// moves first. A multi-jump is a single GameMove whose Path lists the
+// squares landed on before To.
+type checkers struct {
+	baseGame
+	board     [checkersSize][checkersSize]int
+	quiet     int
+	positions []checkersPosition
+	seen      map[checkersPosition]int
+	undo      []checkersUndo
+}
+
+type checkersPosition struct {
+	board [checkersSize][checkersSize]int
+	turn  int
+}
+
+type checkersUndo struct {
+	piece    int
+	captured map[Position]int
+	quiet    int
+}
+
+func newCheckers() Factory {
+	return func(opts *Options, updator func(GameUpdate)) (Game, error) {
+		game := &checkers{
+			baseGame: newBase(2, "checkers", opts, updator),
+			seen:     make(map[checkersPosition]int),
+		}
+		for row := range checkersSize {
+			for col := range checkersSize {
+				if (row+col)%2 == 0 {
+					continue
+				}
+				switch {
+				case row < 3:
+					game.board[row][col] = 2
+				case row > 4:
+					game.board[row][col] = 1
+				}
+			}
+		}
+		game.self = game
+		game.rememberLocked(0)
+		return game, nil
+	}
+}
+
+func (c *checkers) getBoardLocked() any {
+	return c.board
+}
+
+func (c *checkers) getValidMovesLocked() []GameMove {
+	if c.status != StatusInProgress {
+		return []GameMove{}
+	}
+	return c.legalMovesLocked(c.turn)
+}
+
+func (c *checkers) Move(sender string, mv *GameMove) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	idx, err := c.checkTurnLocked(sender)
+	if err != nil {
+		return err
+	}
+
+	var matches []GameMove
+	for _, legal := range c.legalMovesLocked(idx) {
+		if legal.From == mv.From && legal.To == mv.To &&
+			(mv.Path == nil || slices.Equal(legal.Path, mv.Path)) {
+			matches = append(matches, legal)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return errors.New("invalid move")
+	case 1:
+	default:
+		return errors.New("ambiguous jump: give the path")
+	}
+	move := matches[0]
+
+	piece := c.board[move.From.Row][move.From.Col]
+	undo := checkersUndo{piece: piece, quiet: c.quiet}
+	cur := move.From
+	for _, step := range append(slices.Clone(move.Path), move.To) {
+		if abs(step.Row-cur.Row) == 2 {
+			mid := Position{Row: (cur.Row + step.Row) / 2, Col: (cur.Col + step.Col) / 2}
+			if undo.captured == nil {
+				undo.captured = make(map[Position]int)
+			}
+			undo.captured[mid] = c.board[mid.Row][mid.Col]
+			c.board[mid.Row][mid.Col] = 0
+		}
+		cur = step
+	}
+	c.board[move.From.Row][move.From.Col] = 0
+	if piece < checkersKing && move.To.Row == crownRow(idx) {
+		c.board[move.To.Row][move.To.Col] = piece + checkersKing
+	} else {
+		c.board[move.To.Row][move.To.Col] = piece
+	}
+	if len(undo.captured) > 0 || piece < checkersKing {
+		c.quiet = 0
+	} else {
+		c.quiet++
+	}
+	c.undo = append(c.undo, undo)
+
+	next := 1 - idx
+	repeated := c.rememberLocked(next)
+	switch {
+	case len(c.legalMovesLocked(next)) == 0:
+		c.finishLocked(c.players[idx], ReasonNoMoves)
+	case repeated >= 3:
+		c.finishLocked("", ReasonRepetition)
+	case c.quiet >= checkersQuietPlys:
+		c.finishLocked("", ReasonMoveRule)
+	}
+
+	c.endTurnLocked(&move)
+	c.notifyLocked(UpdateAction)
+	return nil
+}
+
+func (c *checkers) undoableLocked() int {
+	return len(c.undo)
+}
+
+func (c *checkers) undoLocked(mv GameMove) error {
+	if len(c.undo) == 0 {
+		return errors.New("no move to take back")
+	}
+	undo := c.undo[len(c.undo)-1]
+	c.undo = c.undo[:len(c.undo)-1]
+
+	last := c.positions[len(c.positions)-1]
+	c.positions = c.positions[:len(c.positions)-1]
+	c.seen[last]--
+
+	c.board[mv.To.Row][mv.To.Col] = 0
+	c.board[mv.From.Row][mv.From.Col] = undo.piece
+	for pos, piece := range undo.captured {
+		c.board[pos.Row][pos.Col] = piece
+	}
+	c.quiet = undo.quiet
+	return nil
+}
+
+// rememberLocked records the board with turn to move and returns how often
+// that position has now been seen.
+func (c *checkers) rememberLocked(turn int) int {
+	pos := checkersPosition{board: c.board, turn: turn}
+	c.positions = append(c.positions, pos)
+	c.seen[pos]++
+	return c.seen[pos]
+}
+
+// legalMovesLocked lists seat's moves. Captures are compulsory, and a jump
+// has to be followed through until the piece can jump no more or is crowned.
+func (c *checkers) legalMovesLocked(seat int) []GameMove {
+	jumps := []GameMove{}
+	steps := []GameMove{}
+	for row := range checkersSize {
+		for col := range checkersSize {
+			piece := c.board[row][col]
+			if piece == 0 || piece%checkersKing != seat+1 {
+				continue
+			}
+			from := Position{Row: row, Col: col}
+			board := c.board
+			board[row][col] = 0
+			c.collectJumps(&board, seat, piece, from, from, nil, &jumps)
+			for _, dir := range checkersDirs(seat, piece) {
+				to := Position{Row: row + dir[0], Col: col + dir[1]}
+				if onCheckersBoard(to) && c.board[to.Row][to.Col] == 0 {
+					steps = append(steps, GameMove{From: from, To: to})
+				}
+			}
+		}
+	}
+	if len(jumps) > 0 {
+		return jumps
+	}
+	return steps
+}
+
+// collectJumps extends the jump that has reached at via path. Captured
+// pieces are marked -1 so they can be neither jumped again nor landed on.
+func (c *checkers) collectJumps(board *[checkersSize][checkersSize]int, seat, piece int,
+	from, at Position, path []Position, jumps *[]GameMove) {
+	extended := false
+	if at == from || piece >= checkersKing || at.Row != crownRow(seat) {
+		for _, dir := range checkersDirs(seat, piece) {
+			mid := Position{Row: at.Row + dir[0], Col: at.Col + dir[1]}
+			to := Position{Row: at.Row + 2*dir[0], Col: at.Col + 2*dir[1]}
+			if !onCheckersBoard(to) || board[to.Row][to.Col] != 0 {
+				continue
+			}
+			captured := board[mid.Row][mid.Col]
+			if captured <= 0 || captured%checkersKing == seat+1 {
+				continue
+			}
+			extended = true
+			board[mid.Row][mid.Col] = -1
+			next := path
+			if at != from {
+				next = append(slices.Clone(path), at)
+			}
+			c.collectJumps(board, seat, piece, from, to, next, jumps)
+			board[mid.Row][mid.Col] = captured
+		}
+	}
+	if !extended && at != from {
+		*jumps = append(*jumps, GameMove{From: from, To: at, Path: path})
+	}
+}
+
+func checkersDirs(seat, piece int) [][2]int {
+	forward := -1
+	if seat == 1 {
+		forward = 1
+	}
+	if piece >= checkersKing {
+		return [][2]int{{forward, -1}, {forward, 1}, {-forward, -1}, {-forward, 1}}
+	}
+	return [][2]int{{forward, -1}, {forward, 1}}
+}
+
+func crownRow(seat int) int {
+	if seat == 0 {
+		return 0
+	}
+	return checkersSize - 1
+}
+
+func onCheckersBoard(p Position) bool {
+	return p.Row >= 0 && p.Row < checkersSize && p.Col >= 0 && p.Col < checkersSize
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
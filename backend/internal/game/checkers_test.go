@@ -0,0 +1,134 @@
+package game
+
+import "testing"
+
+const (
+	whiteKing = 1 + checkersKing
+	blackKing = 2 + checkersKing
+)
+
+// newCheckersAt starts a game between "white" (seat 0) and "black" from
+// pieces instead of the usual setup.
+func newCheckersAt(t *testing.T, pieces map[Position]int) *checkers {
+	t.Helper()
+	g, err := newCheckers()(&Options{}, func(GameUpdate) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(g.Stop)
+	for _, player := range []string{"white", "black"} {
+		if err := g.Join(player); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := g.(*checkers)
+	c.board = [checkersSize][checkersSize]int{}
+	for at, piece := range pieces {
+		c.board[at.Row][at.Col] = piece
+	}
+	c.positions, c.seen = nil, make(map[checkersPosition]int)
+	c.rememberLocked(0)
+	return c
+}
+
+func step(t *testing.T, c *checkers, player string, from, to Position) {
+	t.Helper()
+	if err := c.Move(player, &GameMove{From: from, To: to}); err != nil {
+		t.Fatalf("%s %v-%v: %v", player, from, to, err)
+	}
+}
+
+func TestCheckersRepetition(t *testing.T) {
+	w1, w2 := Position{Row: 6, Col: 1}, Position{Row: 5, Col: 2}
+	b1, b2 := Position{Row: 1, Col: 2}, Position{Row: 2, Col: 3}
+	c := newCheckersAt(t, map[Position]int{w1: whiteKing, b1: blackKing})
+
+	for ply := range 8 {
+		if status := c.GetState().Status; status != StatusInProgress {
+			t.Fatalf("game over after %d plies", ply)
+		}
+		switch ply % 4 {
+		case 0:
+			step(t, c, "white", w1, w2)
+		case 1:
+			step(t, c, "black", b1, b2)
+		case 2:
+			step(t, c, "white", w2, w1)
+		case 3:
+			step(t, c, "black", b2, b1)
+		}
+	}
+	if state := c.GetState(); state.Status != StatusFin || state.Reason != ReasonRepetition || state.Winner != "" {
+		t.Errorf("third repetition left the game %s with reason %q", state.Status, state.Reason)
+	}
+}
+
+// TestCheckersQuietMoves plays 40 king moves each without a capture and
+// without repeating any position three times. White tours every placement of
+// its two kings on their own squares twice over; black goes round its own
+// squares until, on its last lap, it steps away to new ones.
+func TestCheckersQuietMoves(t *testing.T) {
+	a := []Position{{Row: 6, Col: 1}, {Row: 5, Col: 2}, {Row: 6, Col: 3}, {Row: 7, Col: 2}}
+	b := []Position{{Row: 6, Col: 5}, {Row: 5, Col: 6}, {Row: 6, Col: 7}, {Row: 7, Col: 6}}
+	black := []Position{{Row: 1, Col: 2}, {Row: 0, Col: 3}, {Row: 1, Col: 4}, {Row: 2, Col: 3}}
+	away := []Position{{Row: 3, Col: 2}, {Row: 2, Col: 1}}
+	c := newCheckersAt(t, map[Position]int{a[0]: whiteKing, b[0]: whiteKing, black[0]: blackKing})
+
+	// White's kings walk a snake through all 16 pairs of squares and back.
+	var tour [][2]int
+	for j := range 4 {
+		for i := range 4 {
+			if j%2 == 1 {
+				i = 3 - i
+			}
+			tour = append(tour, [2]int{i, j})
+		}
+	}
+	at := black[0]
+	for move := 1; move <= checkersQuietPlys/2; move++ {
+		if status := c.GetState().Status; status != StatusInProgress {
+			t.Fatalf("game over after %d plies", 2*(move-1))
+		}
+		prev, next := tour[(move-1)%len(tour)], tour[move%len(tour)]
+		if prev[0] != next[0] {
+			step(t, c, "white", a[prev[0]], a[next[0]])
+		} else {
+			step(t, c, "white", b[prev[1]], b[next[1]])
+		}
+
+		to := black[move%len(black)]
+		if move >= 2*len(tour) {
+			to = away[move%len(away)]
+		}
+		if status := c.GetState().Status; status != StatusInProgress {
+			t.Fatalf("game over after %d plies", 2*move-1)
+		}
+		step(t, c, "black", at, to)
+		at = to
+	}
+	if state := c.GetState(); state.Status != StatusFin || state.Reason != ReasonMoveRule || state.Winner != "" {
+		t.Errorf("%d quiet plies left the game %s with reason %q", checkersQuietPlys, state.Status, state.Reason)
+	}
+}
+
+func TestCheckersCrowningEndsJump(t *testing.T) {
+	man := Position{Row: 2, Col: 1}
+	crown := Position{Row: 0, Col: 3}
+	// A king landing on crown could jump on over next.
+	next := Position{Row: 1, Col: 4}
+	c := newCheckersAt(t, map[Position]int{man: 1, {Row: 1, Col: 2}: 2, next: 2})
+
+	moves := c.GetState().ValidMoves
+	if len(moves) != 1 || moves[0].From != man || moves[0].To != crown || len(moves[0].Path) != 0 {
+		t.Fatalf("valid moves %+v, want only the jump to the crown row", moves)
+	}
+	step(t, c, "white", man, crown)
+	state := c.GetState()
+	board := state.Board.([checkersSize][checkersSize]int)
+	if board[crown.Row][crown.Col] != whiteKing || board[next.Row][next.Col] != 2 {
+		t.Errorf("after crowning, the king is %d and the next piece %d", board[crown.Row][crown.Col], board[next.Row][next.Col])
+	}
+	if state.Players[state.Turn] != "black" {
+		t.Error("white moves again after crowning")
+	}
+}
@@ -2,8 +2,10 @@ package game
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
-	"time"
+	"slices"
+	"strings"
 
 	"github.com/corentings/chess/v2"
 )
@@ -12,19 +14,70 @@ type chessGame struct {
 	baseGame
 	GameName string
 	game     *chess.Game
+	imported int // moves that came with a PGN start, which cannot be taken back
 }
 
 func newChess() Factory {
-	return func(updator func(GameUpdate)) (Game, error) {
+	return func(opts *Options, updator func(GameUpdate)) (Game, error) {
+		start, err := startingGame(opts)
+		if err != nil {
+			return nil, err
+		}
+		switch start.Outcome() {
+		case chess.WhiteWon, chess.BlackWon, chess.Draw:
+			return nil, errors.New("starting position is already decided")
+		}
 		game := &chessGame{
-			baseGame: newBase(2, "chess", updator),
-			game:     chess.NewGame(),
+			baseGame: newBase(2, "chess", opts, updator),
+			game:     start,
+			imported: len(start.Moves()),
 		}
+		game.turn = colorSeat(start.Position().Turn())
 		game.self = game
 		return game, nil
 	}
 }
 
+func startingGame(opts *Options) (*chess.Game, error) {
+	switch {
+	case opts.PGN != "":
+		text := strings.TrimSpace(opts.PGN)
+		if !strings.HasPrefix(text, "[") {
+			// the PGN reader only picks up games that open with a tag
+			text = "[Event \"?\"]\n\n" + text
+		}
+		pgn, err := chess.PGN(strings.NewReader(text))
+		if err != nil {
+			return nil, fmt.Errorf("invalid PGN: %w", err)
+		}
+		// replay the main line onto a clean game; the parsed one does not
+		// leave its current move at the end of the line
+		parsed := chess.NewGame(pgn)
+		fen, err := chess.FEN(parsed.GetRootMove().Position().String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid PGN: %w", err)
+		}
+		game := chess.NewGame(fen)
+		for _, m := range parsed.Moves() {
+			move := findMove(game, m.S1(), m.S2(), m.Promo())
+			if move == nil {
+				return nil, errors.New("invalid PGN: illegal move " + m.String())
+			}
+			if err := game.Move(move, nil); err != nil {
+				return nil, fmt.Errorf("invalid PGN: %w", err)
+			}
+		}
+		return game, nil
+	case opts.FEN != "":
+		fen, err := chess.FEN(opts.FEN)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FEN: %w", err)
+		}
+		return chess.NewGame(fen), nil
+	}
+	return chess.NewGame(), nil
+}
+
 func (c *chessGame) getBoardLocked() any {
 	board := make([][]int, 8)
 	for i := range 8 {
@@ -57,6 +110,7 @@ func (c *chessGame) getValidMovesLocked() []GameMove {
 					Row: 7 - int(to.Rank()),
 					Col: int(to.File()),
 				},
+				Change: piece2Change(mv.Promo()),
 			})
 		}
 	}
@@ -74,15 +128,7 @@ func (c *chessGame) Move(sender string, mv *GameMove) error {
 
 	from := chess.Square((7-mv.From.Row)*8 + mv.From.Col)
 	to := chess.Square((7-mv.To.Row)*8 + mv.To.Col)
-	promo := change2Piece(mv.Change)
-
-	var move *chess.Move
-	for _, m := range c.game.ValidMoves() {
-		if m.S1() == from && m.S2() == to && m.Promo() == promo {
-			move = &m
-			break
-		}
-	}
+	move := findMove(c.game, from, to, change2Piece(mv.Change))
 	if move == nil {
 		return errors.New("invalid move: not legal in this position")
 	}
@@ -92,28 +138,163 @@ func (c *chessGame) Move(sender string, mv *GameMove) error {
 		return errors.New("internal error")
 	}
 
-	if c.game.Outcome() != chess.NoOutcome {
-		c.status = StatusFin
-		c.endedAt = time.Now()
-		switch c.game.Outcome() {
-		case chess.WhiteWon:
-			c.status = StatusFin
-			c.winner = c.players[0]
-		case chess.BlackWon:
-			c.status = StatusFin
-			c.winner = c.players[1]
-		case chess.Draw:
-			c.status = StatusFin
-		}
-	}
-	c.turn = 1 - c.turn
-	c.notify(GameUpdate{
-		State:  c.stateLocked(),
-		Action: UpdateAction,
-	})
+	switch c.game.Outcome() {
+	case chess.WhiteWon:
+		c.finishLocked(c.players[0], chessReason(c.game.Method()))
+	case chess.BlackWon:
+		c.finishLocked(c.players[1], chessReason(c.game.Method()))
+	case chess.Draw:
+		c.finishLocked("", chessReason(c.game.Method()))
+	}
+	c.endTurnLocked(mv)
+	c.notifyLocked(UpdateAction)
+	return nil
+}
+
+// handleFlagLocked only awards the win on time if the opponent could still
+// deliver mate; otherwise the game is drawn.
+func (c *chessGame) handleFlagLocked() {
+	opponent := 1 - c.turn
+	winner := c.players[opponent]
+	if !canMate(c.game.Position().Board(), seatColor(opponent)) {
+		winner = ""
+	}
+	c.finishLocked(winner, ReasonTimeout)
+	c.notifyLocked(UpdateAction)
+}
+
+func (c *chessGame) undoableLocked() int {
+	return len(c.game.Moves()) - c.imported
+}
+
+// undoLocked replays the game without its last move; the move tree of the
+// original game is left alone so the PGN carries no stray variations.
+func (c *chessGame) undoLocked(GameMove) error {
+	moves := c.game.Moves()
+	if len(moves) <= c.imported {
+		return errors.New("no move to take back")
+	}
+	game, err := startingGame(c.opts)
+	if err != nil {
+		return err
+	}
+	for _, m := range moves[c.imported : len(moves)-1] {
+		move := findMove(game, m.S1(), m.S2(), m.Promo())
+		if move == nil {
+			return errors.New("internal error")
+		}
+		if err := game.Move(move, nil); err != nil {
+			slog.Error("Internal error", "error", err)
+			return errors.New("internal error")
+		}
+	}
+	c.game = game
+	return nil
+}
+
+func findMove(game *chess.Game, from, to chess.Square, promo chess.PieceType) *chess.Move {
+	for _, m := range game.ValidMoves() {
+		if m.S1() == from && m.S2() == to && m.Promo() == promo {
+			return &m
+		}
+	}
 	return nil
 }
 
+func chessReason(method chess.Method) string {
+	switch method {
+	case chess.Checkmate:
+		return ReasonCheckmate
+	case chess.Stalemate:
+		return ReasonStalemate
+	case chess.ThreefoldRepetition, chess.FivefoldRepetition:
+		return ReasonRepetition
+	case chess.FiftyMoveRule, chess.SeventyFiveMoveRule:
+		return ReasonMoveRule
+	case chess.InsufficientMaterial:
+		return ReasonNoMaterial
+	}
+	return ""
+}
+
+func seatColor(seat int) chess.Color {
+	if seat == 0 {
+		return chess.White
+	}
+	return chess.Black
+}
+
+func colorSeat(color chess.Color) int {
+	if color == chess.Black {
+		return 1
+	}
+	return 0
+}
+
+func (c *chessGame) notationLocked() *Notation {
+	return &Notation{
+		FEN: c.game.FEN(),
+		PGN: c.pgnLocked(),
+	}
+}
+
+// pgnLocked writes the game out with our own tags rather than through
+// chess.Game, whose tags and result would have to be mutated under a read lock.
+func (c *chessGame) pgnLocked() string {
+	seats := c.seats
+	if seats == nil {
+		seats = c.players
+	}
+	names := [2]string{"?", "?"}
+	copy(names[:], seats)
+	result := "*" // also for games abandoned by both sides
+	if c.status == StatusFin {
+		switch {
+		case c.winner == names[0]:
+			result = "1-0"
+		case c.winner != "":
+			result = "0-1"
+		case slices.Contains(drawReasons, c.reason):
+			result = "1/2-1/2"
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[Event \"GoNext chess\"]\n[White \"%s\"]\n[Black \"%s\"]\n[Result \"%s\"]\n",
+		names[0], names[1], result)
+	if start := c.game.GetRootMove().Position().String(); start != chess.StartingPosition().String() {
+		fmt.Fprintf(&sb, "[SetUp \"1\"]\n[FEN \"%s\"]\n", start)
+	}
+	sb.WriteString("\n")
+	for i, m := range c.game.Moves() {
+		pos := m.Parent().Position()
+		if pos.Turn() == chess.White {
+			fmt.Fprintf(&sb, "%d. ", (pos.Ply()+1)/2)
+		} else if i == 0 {
+			fmt.Fprintf(&sb, "%d... ", (pos.Ply()+1)/2)
+		}
+		sb.WriteString(chess.AlgebraicNotation{}.Encode(pos, m) + " ")
+	}
+	sb.WriteString(result)
+	return sb.String()
+}
+
+func canMate(board *chess.Board, color chess.Color) bool {
+	minors := 0
+	for _, piece := range board.SquareMap() {
+		if piece.Color() != color {
+			continue
+		}
+		switch piece.Type() {
+		case chess.Queen, chess.Rook, chess.Pawn:
+			return true
+		case chess.Bishop, chess.Knight:
+			minors++
+		}
+	}
+	return minors >= 2
+}
+
 func change2Piece(change string) chess.PieceType {
 	switch change {
 	case "q", "Q":
@@ -128,6 +309,20 @@ func change2Piece(change string) chess.PieceType {
 	return chess.NoPieceType
 }
 
+func piece2Change(piece chess.PieceType) string {
+	switch piece {
+	case chess.Queen:
+		return "q"
+	case chess.Rook:
+		return "r"
+	case chess.Bishop:
+		return "b"
+	case chess.Knight:
+		return "n"
+	}
+	return ""
+}
+
 func pieceToCode(piece chess.Piece) int {
 	var code int
 	switch piece.Type() {
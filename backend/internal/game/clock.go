@@ -0,0 +1,142 @@
+package game
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+type TimeControl struct {
+	Initial   int `json:"initial"`   // seconds on each player's clock, 0 for none
+	Increment int `json:"increment"` // seconds added after each move
+	PerMove   int `json:"perMove"`   // seconds allowed for a single move, 0 for none
+}
+
+func (tc *TimeControl) validate() error {
+	if tc.Initial < 0 || tc.Increment < 0 || tc.PerMove < 0 {
+		return errors.New("time control cannot be negative")
+	}
+	if tc.Initial == 0 && tc.PerMove == 0 {
+		return errors.New("time control needs an initial or per-move limit")
+	}
+	return nil
+}
+
+type ClockState struct {
+	Remaining     []int64 `json:"remaining,omitempty"`
+	MoveRemaining int64   `json:"moveRemaining,omitempty"`
+	Running       bool    `json:"running"`
+}
+
+type clock struct {
+	initial   time.Duration
+	increment time.Duration
+	perMove   time.Duration
+	remaining []time.Duration
+	moveUsed  time.Duration
+	since     time.Time // zero while paused
+}
+
+func newClock(tc *TimeControl, seats int) *clock {
+	if tc == nil {
+		return nil
+	}
+	c := &clock{
+		initial:   time.Duration(tc.Initial) * time.Second,
+		increment: time.Duration(tc.Increment) * time.Second,
+		perMove:   time.Duration(tc.PerMove) * time.Second,
+		remaining: make([]time.Duration, seats),
+	}
+	for i := range c.remaining {
+		c.remaining[i] = c.initial
+	}
+	return c
+}
+
+func (c *clock) start(now time.Time) {
+	if c.since.IsZero() {
+		c.since = now
+	}
+}
+
+func (c *clock) pause(turn int, now time.Time) {
+	if c.since.IsZero() {
+		return
+	}
+	elapsed := now.Sub(c.since)
+	if c.initial > 0 {
+		c.remaining[turn] -= elapsed
+	}
+	c.moveUsed += elapsed
+	c.since = time.Time{}
+}
+
+// press ends turn's move: the mover gets their increment and the next move
+// starts with a fresh per-move allowance.
+func (c *clock) press(turn int, now time.Time) {
+	c.pause(turn, now)
+	if c.initial > 0 {
+		c.remaining[turn] += c.increment
+	}
+	c.restart(now)
+}
+
+// restart begins a new move without crediting anyone, e.g. after a takeback.
+func (c *clock) restart(now time.Time) {
+	c.since = time.Time{}
+	c.moveUsed = 0
+	c.start(now)
+}
+
+// snapshot returns the time left on each clock and used on this move, as of
+// now.
+func (c *clock) snapshot(turn int, now time.Time) ([]time.Duration, time.Duration) {
+	remaining, used := slices.Clone(c.remaining), c.moveUsed
+	if !c.since.IsZero() {
+		elapsed := now.Sub(c.since)
+		if c.initial > 0 {
+			remaining[turn] -= elapsed
+		}
+		used += elapsed
+	}
+	return remaining, used
+}
+
+// restore sets the clocks back to a snapshot, paused.
+func (c *clock) restore(remaining []time.Duration, used time.Duration) {
+	copy(c.remaining, remaining)
+	c.moveUsed = used
+	c.since = time.Time{}
+}
+
+func (c *clock) flagged(turn int, now time.Time) bool {
+	var elapsed time.Duration
+	if !c.since.IsZero() {
+		elapsed = now.Sub(c.since)
+	}
+	if c.initial > 0 && c.remaining[turn]-elapsed <= 0 {
+		return true
+	}
+	return c.perMove > 0 && c.moveUsed+elapsed >= c.perMove
+}
+
+func (c *clock) state(turn int, now time.Time) *ClockState {
+	var elapsed time.Duration
+	if !c.since.IsZero() {
+		elapsed = now.Sub(c.since)
+	}
+	state := &ClockState{Running: !c.since.IsZero()}
+	if c.initial > 0 {
+		state.Remaining = make([]int64, len(c.remaining))
+		for i, left := range c.remaining {
+			if i == turn {
+				left -= elapsed
+			}
+			state.Remaining[i] = max(left, 0).Milliseconds()
+		}
+	}
+	if c.perMove > 0 {
+		state.MoveRemaining = max(c.perMove-c.moveUsed-elapsed, 0).Milliseconds()
+	}
+	return state
+}
@@ -0,0 +1,76 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockIncrement(t *testing.T) {
+	c := newClock(&TimeControl{Initial: 60, Increment: 2}, 2)
+	now := time.Unix(0, 0)
+	c.start(now)
+
+	now = now.Add(10 * time.Second)
+	c.press(0, now)
+	now = now.Add(5 * time.Second)
+	remaining, used := c.snapshot(1, now)
+	if remaining[0] != 52*time.Second || remaining[1] != 55*time.Second || used != 5*time.Second {
+		t.Errorf("clocks %v with %v used, want [52s 55s] with 5s", remaining, used)
+	}
+	if state := c.state(1, now); state.Remaining[0] != 52000 || state.Remaining[1] != 55000 || !state.Running {
+		t.Errorf("state %+v", state)
+	}
+}
+
+func TestClockPause(t *testing.T) {
+	c := newClock(&TimeControl{Initial: 60}, 2)
+	now := time.Unix(0, 0)
+	c.start(now)
+	now = now.Add(10 * time.Second)
+	c.pause(0, now)
+
+	now = now.Add(time.Hour)
+	if remaining, _ := c.snapshot(0, now); remaining[0] != 50*time.Second {
+		t.Errorf("paused clock ran on to %v", remaining[0])
+	}
+	if c.flagged(0, now) {
+		t.Error("paused clock flagged")
+	}
+	c.start(now)
+	if c.flagged(0, now.Add(49*time.Second)) || !c.flagged(0, now.Add(50*time.Second)) {
+		t.Error("clock did not flag once its 50s ran out")
+	}
+}
+
+func TestClockPerMove(t *testing.T) {
+	c := newClock(&TimeControl{PerMove: 10}, 2)
+	now := time.Unix(0, 0)
+	c.start(now)
+	if c.flagged(0, now.Add(9*time.Second)) || !c.flagged(0, now.Add(10*time.Second)) {
+		t.Fatal("move did not flag after 10s")
+	}
+
+	now = now.Add(9 * time.Second)
+	c.press(0, now)
+	if c.flagged(1, now.Add(9*time.Second)) {
+		t.Error("the next move did not get a fresh 10s")
+	}
+	if state := c.state(1, now.Add(4*time.Second)); state.Remaining != nil || state.MoveRemaining != 6000 {
+		t.Errorf("state %+v, want no clocks and 6s for the move", state)
+	}
+}
+
+func TestClockRestore(t *testing.T) {
+	c := newClock(&TimeControl{Initial: 60, PerMove: 30}, 2)
+	now := time.Unix(0, 0)
+	c.start(now)
+	remaining, used := c.snapshot(0, now.Add(20*time.Second))
+
+	restored := newClock(&TimeControl{Initial: 60, PerMove: 30}, 2)
+	restored.restore(remaining, used)
+	later := now.Add(time.Hour)
+	restored.start(later)
+	if restored.flagged(0, later.Add(9*time.Second)) || !restored.flagged(0, later.Add(10*time.Second)) {
+		t.Error("restored move did not keep its 20s used")
+	}
+}
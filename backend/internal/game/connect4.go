@@ -2,7 +2,6 @@ package game
 
 import (
 	"errors"
-	"time"
 )
 
 type connect4 struct {
@@ -12,9 +11,9 @@ type connect4 struct {
 }
 
 func newConnect4() Factory {
-	return func(updator func(GameUpdate)) (Game, error) {
+	return func(opts *Options, updator func(GameUpdate)) (Game, error) {
 		game := &connect4{
-			baseGame: newBase(2, "connect4", updator),
+			baseGame: newBase(2, "connect4", opts, updator),
 			board:    [6][7]int{},
 		}
 		game.self = game
@@ -26,6 +25,23 @@ func (c *connect4) getBoardLocked() any {
 	return c.board
 }
 
+// getValidMovesLocked lists one move per open column, aimed at the cell the
+// disc would land on; Move itself only looks at the column.
+func (c *connect4) getValidMovesLocked() []GameMove {
+	validMoves := []GameMove{}
+	if c.status == StatusInProgress {
+		for col := range 7 {
+			for row := 5; row >= 0; row-- {
+				if c.board[row][col] == 0 {
+					validMoves = append(validMoves, GameMove{To: Position{Row: row, Col: col}})
+					break
+				}
+			}
+		}
+	}
+	return validMoves
+}
+
 func (c *connect4) Move(sender string, mv *GameMove) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -53,21 +69,29 @@ func (c *connect4) Move(sender string, mv *GameMove) error {
 	}
 
 	if win := c.checkWinner(droppedRow, mv.To.Col); win != 0 {
-		c.status = StatusFin
-		c.winner = c.players[win-1]
-		c.endedAt = time.Now()
+		c.finishLocked(c.players[win-1], ReasonLine)
 	} else if c.checkDraw() {
-		c.status = StatusFin
-		c.endedAt = time.Now()
+		c.finishLocked("", ReasonBoardFull)
 	}
-	c.turn = 1 - c.turn
-	c.notify(GameUpdate{
-		State:  c.stateLocked(),
-		Action: UpdateAction,
-	})
+	c.endTurnLocked(mv)
+	c.notifyLocked(UpdateAction)
 	return nil
 }
 
+func (c *connect4) undoableLocked() int {
+	return len(c.history)
+}
+
+func (c *connect4) undoLocked(mv GameMove) error {
+	for row := range 6 {
+		if c.board[row][mv.To.Col] != 0 {
+			c.board[row][mv.To.Col] = 0
+			return nil
+		}
+	}
+	return errors.New("column is empty")
+}
+
 func (c *connect4) checkWinner(startRow, startCol int) int {
 	player := c.board[startRow][startCol]
 	if player == 0 {
@@ -1,13 +1,41 @@
 package game
 
 import (
+	"errors"
 	"fmt"
 )
 
-type Factory func(updator func(GameUpdate)) (Game, error)
+type Factory func(opts *Options, updator func(GameUpdate)) (Game, error)
+
+type Options struct {
+	TimeControl *TimeControl `json:"timeControl,omitempty"`
+	FEN         string       `json:"fen,omitempty"`       // chess only
+	PGN         string       `json:"pgn,omitempty"`       // chess only
+	BoardSize   int          `json:"boardSize,omitempty"` // go only
+	Players     int          `json:"players,omitempty"`   // games for a varying number of players
+	Teams       int          `json:"teams,omitempty"`     // 0 for everyone for themselves
+}
+
+func (o *Options) validate() error {
+	if o.FEN != "" && o.PGN != "" {
+		return errors.New("start from either a FEN or a PGN, not both")
+	}
+	if o.Players < 0 || o.Teams < 0 {
+		return errors.New("players and teams cannot be negative")
+	}
+	if o.TimeControl != nil {
+		if err := o.TimeControl.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
 type GameInfo struct {
 	Factory Factory
+	Seats   int    // players a game sits down, unless Options.Players sets it
+	Varying bool   // whether Options.Players can
+	Rules   *Rules // for games loaded from a rule file
 }
 
 type Registry struct {
@@ -18,22 +46,50 @@ func NewRegistry() *Registry {
 	return &Registry{games: make(map[string]GameInfo)}
 }
 
-func (r *Registry) register(name string, factory Factory) {
-	r.games[name] = GameInfo{
-		Factory: factory,
-	}
+func (r *Registry) register(name string, info GameInfo) {
+	r.games[name] = info
 }
 
 func (r *Registry) RegisterAll() {
-	r.register("tictactoe", newTicTacToe())
-	r.register("connect4", newConnect4())
-	r.register("chess", newChess())
+	r.register("tictactoe", GameInfo{Factory: newTicTacToe(), Seats: 2})
+	r.register("connect4", GameInfo{Factory: newConnect4(), Seats: 2})
+	r.register("chess", GameInfo{Factory: newChess(), Seats: 2})
+	r.register("checkers", GameInfo{Factory: newCheckers(), Seats: 2})
+	r.register("othello", GameInfo{Factory: newOthello(), Seats: 2})
+	r.register("go", GameInfo{Factory: newGo(), Seats: 2})
+	r.register("trails", GameInfo{Factory: newTrails(), Seats: trailsPlayers, Varying: true})
+	r.register("battleship", GameInfo{Factory: newBattleship(), Seats: 2})
 }
 
-func (r *Registry) Create(name string, updator func(GameUpdate)) (Game, error) {
-	info, ok := r.games[name]
-	if !ok {
-		return nil, fmt.Errorf("game type not supported: %s", name)
+// Validate checks that a game of this name can be created with opts.
+func (r *Registry) Validate(name string, opts *Options) error {
+	if _, ok := r.games[name]; !ok {
+		return fmt.Errorf("game type not supported: %s", name)
+	}
+	if opts == nil {
+		return nil
+	}
+	return opts.validate()
+}
+
+func (r *Registry) Create(name string, opts *Options, updator func(GameUpdate)) (Game, error) {
+	if err := r.Validate(name, opts); err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+	return r.games[name].Factory(opts, updator)
+}
+
+// Seats returns how many players a game of this name with opts sits down.
+func (r *Registry) Seats(name string, opts *Options) (int, error) {
+	if err := r.Validate(name, opts); err != nil {
+		return 0, err
+	}
+	info := r.games[name]
+	if info.Varying && opts != nil && opts.Players != 0 {
+		return opts.Players, nil
 	}
-	return info.Factory(updator)
+	return info.Seats, nil
 }
@@ -0,0 +1,25 @@
+package game
+
+import "testing"
+
+func TestSeats(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterAll()
+	if err := registry.LoadRules("../../games"); err != nil {
+		t.Fatal(err)
+	}
+
+	for name := range registry.games {
+		for _, opts := range []*Options{nil, {Players: 3}} {
+			g, err := registry.Create(name, opts, func(GameUpdate) {})
+			if err != nil {
+				continue
+			}
+			want := g.GetState().Seats
+			g.Stop()
+			if seats, err := registry.Seats(name, opts); err != nil || seats != want {
+				t.Errorf("%s with %+v: %d seats (%v), want %d", name, opts, seats, err, want)
+			}
+		}
+	}
+}
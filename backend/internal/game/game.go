@@ -3,9 +3,12 @@ package game
 import (
 	"context"
 	"errors"
+	"fmt"
 	"slices"
 	"sync"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type GameAction int
@@ -24,75 +27,237 @@ const (
 	DeleteAction
 )
 
+const (
+	ReasonLine        = "line"
+	ReasonBoardFull   = "board_full"
+	ReasonCheckmate   = "checkmate"
+	ReasonStalemate   = "stalemate"
+	ReasonRepetition  = "repetition"
+	ReasonMoveRule    = "move_rule"
+	ReasonNoMaterial  = "insufficient_material"
+	ReasonResignation = "resignation"
+	ReasonAgreement   = "agreement"
+	ReasonTimeout     = "timeout"
+	ReasonAbandoned   = "abandoned"
+	ReasonNoMoves     = "no_moves"
+	ReasonScore       = "score"
+	ReasonFleetSunk   = "fleet_sunk"
+	ReasonPasses      = "passes"
+)
+
+// drawReasons are the ways a game ends with no winner that count as a draw,
+// rather than leaving it unresolved. A chess flag fall is one when the
+// opponent could not have mated.
+var drawReasons = []string{ReasonBoardFull, ReasonStalemate, ReasonRepetition, ReasonMoveRule,
+	ReasonNoMaterial, ReasonAgreement, ReasonTimeout, ReasonScore, ReasonPasses}
+
 type Position struct {
 	Row int `json:"row"`
 	Col int `json:"col"`
 }
 
 type GameMove struct {
-	From   Position `json:"from"`
-	To     Position `json:"to"`
-	Change string   `json:"change,omitempty"`
+	From   Position   `json:"from"`
+	To     Position   `json:"to"`
+	Change string     `json:"change,omitempty"`
+	Path   []Position `json:"path,omitempty"` // squares passed through between From and To
+	Pass   bool       `json:"pass,omitempty"`
+	Out    string     `json:"out,omitempty"` // in the history only: the player left for this reason
 }
 
 type Game interface {
 	Join(player string) error
+	JoinSeat(player string, seat int) error
+	Spectate(player string) error
 	Rejoin(player string)
 	Leave(player string, intentional bool)
 	Move(player string, mv *GameMove) error
+	Resign(player string) error
+	OfferDraw(player string) error
+	AcceptDraw(player string) error
+	DeclineDraw(player string) error
+	RequestTakeback(player string) error
+	AcceptTakeback(player string) error
+	DeclineTakeback(player string) error
 	Start()
 	Stop()
 
 	GetState() *GameState
+	StateFor(viewer string) *GameState
+	Record() *Record
+	Snapshot() *Snapshot
 	getBoardLocked() any
 	getValidMovesLocked() []GameMove
+	undoLocked(mv GameMove) error
+	undoableLocked() int
+	clockPausedLocked() bool
+	knockOut(player, reason string) error
+	restore(snap *Snapshot, updator func(GameUpdate))
 	updateLoop()
-	handleDisconnectLocked()
+	handleDisconnectLocked(seats []int)
+	handleFlagLocked()
 }
 
 type GameUpdate struct {
-	State  *GameState
+	State  *GameState // everything, for the server's eyes only
 	Action GameAction
+	Record *Record // set once, on the update that finishes the game
+}
+
+type PlayedMove struct {
+	Seat   int
+	Player string
+	Move   GameMove
+	At     time.Time
+}
+
+type Record struct {
+	ID        string
+	GameName  string
+	Players   []string
+	Winner    string
+	Winners   []string
+	Reason    string
+	Options   *Options
+	StartedAt time.Time
+	EndedAt   time.Time
+	Moves     []PlayedMove
+}
+
+// Drawn reports whether the game ended in a draw.
+func (r *Record) Drawn() bool {
+	return r.Winner == "" && len(r.Winners) == 0 && slices.Contains(drawReasons, r.Reason)
+}
+
+// VisibleTo reports whether user may look at the game: anyone once it is
+// over, only its players while it is going.
+func (r *Record) VisibleTo(user string) bool {
+	return !r.EndedAt.IsZero() || slices.Contains(r.Players, user)
+}
+
+// Snapshot is what a running game needs to come back after a restart: its
+// record so far and its clocks.
+type Snapshot struct {
+	Record
+	Clock    []time.Duration `json:",omitempty"`
+	MoveUsed time.Duration   `json:",omitempty"`
 }
 
 type GameState struct {
-	GameName   string     `json:"gameName"`
-	Players    []string   `json:"players"`
-	Turn       int        `json:"turn"`
-	Board      any        `json:"board"`
-	Status     string     `json:"status"`
-	Winner     string     `json:"winner"`
-	ValidMoves []GameMove `json:"validMoves"`
+	ID               string      `json:"id"`
+	GameName         string      `json:"gameName"`
+	Players          []string    `json:"players"`
+	Seats            int         `json:"seats"`
+	Teams            []int       `json:"teams,omitempty"` // team of each seat, for team games
+	Turn             int         `json:"turn"`
+	Board            any         `json:"board"`
+	Status           string      `json:"status"`
+	Winner           string      `json:"winner"` // set only when there is a single winner
+	Winners          []string    `json:"winners,omitempty"`
+	Reason           string      `json:"reason,omitempty"`
+	Eliminated       []string    `json:"eliminated,omitempty"`
+	ValidMoves       []GameMove  `json:"validMoves"`
+	Spectators       []string    `json:"spectators"`
+	Clock            *ClockState `json:"clock,omitempty"`
+	DrawOffer        string      `json:"drawOffer,omitempty"`
+	DrawAccepted     []string    `json:"drawAccepted,omitempty"`
+	Takeback         string      `json:"takeback,omitempty"`
+	TakebackAccepted []string    `json:"takebackAccepted,omitempty"`
+	Notation         *Notation   `json:"notation,omitempty"`
+	Scoring          *Scoring    `json:"scoring,omitempty"`
+}
+
+type Notation struct {
+	FEN string `json:"fen,omitempty"`
+	PGN string `json:"pgn,omitempty"`
+}
+
+// notated is implemented by games with a standard text notation.
+type notated interface {
+	notationLocked() *Notation
+}
+
+// Scoring is the count for games decided on points, once there is one.
+type Scoring struct {
+	Scores  map[string]float64 `json:"scores"`
+	Marking bool               `json:"marking,omitempty"` // players are agreeing on dead stones
+	Done    []string           `json:"done,omitempty"`    // players happy with the marking
+}
+
+// scored is implemented by games that keep a running score.
+type scored interface {
+	scoringLocked() *Scoring
+}
+
+// hidden is implemented by games where players may not see the whole board.
+// boardForLocked returns what viewer may see of it; spectators are "".
+type hidden interface {
+	boardForLocked(viewer string) any
+}
+
+// simultaneous is implemented by games where at times every player may move,
+// not just the one whose turn it is.
+type simultaneous interface {
+	anyoneMovesLocked() bool
+}
+
+// knockout is implemented by games that play on without a player who has no
+// move when their turn comes, instead of ending.
+type knockout interface {
+	canMoveLocked(seat int) bool
 }
 
 type baseGame struct {
 	self        Game
 	mu          sync.RWMutex
+	id          string
 	gameName    string
+	opts        *Options
 	players     []string
+	wanted      []int // seat asked for by each player, -1 for any, until the game starts
+	seats       []string
+	teams       []int // team of each seat; nil when everyone plays for themselves
+	out         []bool
+	spectators  []string
 	turn        int
 	numPlayers  int
 	status      string
 	disconnects map[string]time.Time
 	notify      func(GameUpdate)
+	clock       *clock
+	history     []PlayedMove
 
-	winner  string
-	endedAt time.Time
+	drawOffer        string
+	drawAccepted     []string
+	takeback         string
+	takebackAccepted []string
+
+	winner    string
+	winners   []string
+	reason    string
+	startedAt time.Time
+	endedAt   time.Time
+	recorded  bool
 
 	ctx    context.Context
 	cancel context.CancelFunc
 }
 
-func newBase(numPlayers int, gameName string, updator func(GameUpdate)) baseGame {
+func newBase(numPlayers int, gameName string, opts *Options, updator func(GameUpdate)) baseGame {
 	ctx, cancel := context.WithCancel(context.Background())
 	return baseGame{
+		id:          uuid.NewString(),
 		gameName:    gameName,
+		opts:        opts,
 		players:     make([]string, 0, numPlayers),
+		out:         make([]bool, numPlayers),
+		spectators:  []string{},
 		turn:        0,
 		numPlayers:  numPlayers,
 		status:      StatusWaiting,
 		disconnects: make(map[string]time.Time),
 		notify:      updator,
+		clock:       newClock(opts.TimeControl, numPlayers),
 		ctx:         ctx,
 		cancel:      cancel,
 	}
@@ -106,7 +271,30 @@ func (b *baseGame) Stop() {
 	b.cancel()
 }
 
+// splitTeams puts the seats into teams of equal size, taking turns so that
+// no two team-mates move one after the other. Zero teams means everyone
+// plays for themselves.
+func (b *baseGame) splitTeams(teams int) error {
+	if teams == 0 {
+		return nil
+	}
+	if teams < 2 || teams >= b.numPlayers || b.numPlayers%teams != 0 {
+		return fmt.Errorf("%d players cannot be split into %d teams", b.numPlayers, teams)
+	}
+	b.teams = make([]int, b.numPlayers)
+	for seat := range b.teams {
+		b.teams[seat] = seat % teams
+	}
+	return nil
+}
+
 func (b *baseGame) Join(player string) error {
+	return b.JoinSeat(player, -1)
+}
+
+// JoinSeat joins the game asking for a seat, or for any seat if it is -1.
+// Seats are handed out once everyone is in, in the order players joined.
+func (b *baseGame) JoinSeat(player string, seat int) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -116,14 +304,65 @@ func (b *baseGame) Join(player string) error {
 	if slices.Contains(b.players, player) {
 		return errors.New("already joined")
 	}
+	if slices.Contains(b.spectators, player) {
+		return errors.New("spectators cannot take a seat")
+	}
+	if seat < -1 || seat >= b.numPlayers {
+		return errors.New("no such seat")
+	}
+	if seat != -1 && slices.Contains(b.wanted, seat) {
+		return errors.New("seat already taken")
+	}
 	b.players = append(b.players, player)
+	b.wanted = append(b.wanted, seat)
 	if len(b.players) == b.numPlayers {
 		b.status = StatusInProgress
+		b.seatPlayersLocked()
+		b.seats = slices.Clone(b.players)
+		b.startedAt = time.Now()
+		if b.clock != nil {
+			b.clock.start(b.startedAt)
+		}
 	}
-	b.notify(GameUpdate{
-		State:  b.stateLocked(),
-		Action: UpdateAction,
-	})
+	b.notifyLocked(UpdateAction)
+	return nil
+}
+
+// seatPlayersLocked puts players who asked for a seat in it and the rest in
+// the free seats, in the order they joined.
+func (b *baseGame) seatPlayersLocked() {
+	seated := make([]string, b.numPlayers)
+	for i, player := range b.players {
+		if b.wanted[i] != -1 {
+			seated[b.wanted[i]] = player
+		}
+	}
+	free := 0
+	for i, player := range b.players {
+		if b.wanted[i] != -1 {
+			continue
+		}
+		for seated[free] != "" {
+			free++
+		}
+		seated[free] = player
+	}
+	b.players = seated
+	b.wanted = nil
+}
+
+func (b *baseGame) Spectate(player string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if slices.Contains(b.players, player) {
+		return errors.New("already playing")
+	}
+	if slices.Contains(b.spectators, player) {
+		return errors.New("already spectating")
+	}
+	b.spectators = append(b.spectators, player)
+	b.notifyLocked(UpdateAction)
 	return nil
 }
 
@@ -133,13 +372,11 @@ func (b *baseGame) Rejoin(player string) {
 
 	if _, ok := b.disconnects[player]; ok {
 		delete(b.disconnects, player)
-		if len(b.disconnects) == 0 {
+		if len(b.disconnects) == 0 && b.status == StatusDisconnected {
 			b.status = StatusInProgress
+			b.startClockLocked(time.Now())
 		}
-		b.notify(GameUpdate{
-			State:  b.stateLocked(),
-			Action: UpdateAction,
-		})
+		b.notifyLocked(UpdateAction)
 	}
 }
 
@@ -147,18 +384,44 @@ func (b *baseGame) Leave(player string, intentional bool) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if idx := slices.Index(b.spectators, player); idx != -1 {
+		b.spectators = slices.Delete(b.spectators, idx, idx+1)
+		b.notifyLocked(UpdateAction)
+		return
+	}
+
 	idx := slices.Index(b.players, player)
 	if idx == -1 {
 		return
 	}
-	if !intentional && (b.status == StatusInProgress || b.status == StatusDisconnected) {
-		b.status = StatusDisconnected
-		b.disconnects[player] = time.Now()
-	} else {
+	if b.status != StatusInProgress && b.status != StatusDisconnected {
 		b.players = slices.Delete(b.players, idx, idx+1)
+		if b.status == StatusWaiting {
+			b.wanted = slices.Delete(b.wanted, idx, idx+1)
+		}
+		if len(b.players) == 0 {
+			b.finishLocked("", ReasonAbandoned)
+			b.notifyLocked(DeleteAction)
+			b.Stop()
+		} else {
+			b.notifyLocked(UpdateAction)
+		}
+		return
+	}
+	if b.out[idx] {
+		return
 	}
 
-	b.handleDisconnectLocked()
+	if intentional {
+		b.knockOutLocked(ReasonAbandoned, idx)
+	} else {
+		if b.clock != nil {
+			b.clock.pause(b.turn, time.Now())
+		}
+		b.status = StatusDisconnected
+		b.disconnects[player] = time.Now()
+	}
+	b.notifyLocked(UpdateAction)
 }
 
 func (b *baseGame) checkTurnLocked(sender string) (int, error) {
@@ -181,10 +444,215 @@ func (b *baseGame) checkTurnLocked(sender string) (int, error) {
 	return idx, nil
 }
 
+// endTurnLocked records mv for the player to move and passes the turn on,
+// pressing the clock if the game goes on.
+func (b *baseGame) endTurnLocked(mv *GameMove) {
+	b.endTurnToLocked(mv, b.nextSeatLocked(b.turn))
+}
+
+// endTurnToLocked is endTurnLocked for games where next is not simply the
+// following seat, e.g. when a player has to pass.
+func (b *baseGame) endTurnToLocked(mv *GameMove, next int) {
+	played := *mv
+	played.Out = ""
+	b.history = append(b.history, PlayedMove{
+		Seat:   b.turn,
+		Player: b.players[b.turn],
+		Move:   played,
+		At:     time.Now(),
+	})
+	if b.clock != nil && b.status == StatusInProgress {
+		b.clock.press(b.turn, time.Now())
+	}
+	if b.drawOffer != "" && b.drawOffer != b.players[b.turn] {
+		b.drawOffer, b.drawAccepted = "", nil
+	}
+	b.takeback, b.takebackAccepted = "", nil
+	b.turn = next
+	b.skipStuckLocked()
+}
+
+// nextSeatLocked is the seat after seat that is still in the game.
+func (b *baseGame) nextSeatLocked(seat int) int {
+	for i := 1; i < b.numPlayers; i++ {
+		if next := (seat + i) % b.numPlayers; !b.out[next] {
+			return next
+		}
+	}
+	return seat
+}
+
+// skipStuckLocked knocks out players who have no move when their turn comes,
+// in games that play on without them.
+func (b *baseGame) skipStuckLocked() {
+	k, ok := b.self.(knockout)
+	if !ok {
+		return
+	}
+	for b.status == StatusInProgress && !k.canMoveLocked(b.turn) {
+		b.eliminateLocked(ReasonNoMoves, b.turn)
+	}
+}
+
+// eliminateLocked takes seats out of the game. Once only one team is left
+// it wins, for reason; otherwise play passes over the seats.
+func (b *baseGame) eliminateLocked(reason string, seats ...int) {
+	now := time.Now()
+	for _, seat := range seats {
+		player := b.players[seat]
+		b.out[seat] = true
+		delete(b.disconnects, player)
+		if b.drawOffer == player {
+			b.drawOffer, b.drawAccepted = "", nil
+		}
+		if b.takeback == player {
+			b.takeback, b.takebackAccepted = "", nil
+		}
+	}
+	if b.status == StatusDisconnected && len(b.disconnects) == 0 {
+		b.status = StatusInProgress
+		b.startClockLocked(now)
+	}
+
+	left := b.teamsLeftLocked()
+	switch len(left) {
+	case 0:
+		b.finishLocked("", reason)
+		return
+	case 1:
+		b.finishLocked(b.players[left[0]], reason)
+		return
+	}
+	if b.out[b.turn] {
+		if b.clock != nil && b.status == StatusInProgress && !b.self.clockPausedLocked() {
+			b.clock.pause(b.turn, now)
+			b.clock.restart(now)
+		}
+		b.turn = b.nextSeatLocked(b.turn)
+	}
+}
+
+// knockOutLocked is eliminateLocked for players leaving between moves. If
+// the game goes on without them, it goes in the history for replays, and
+// any takeback asked for lapses since it could not reach back past it.
+func (b *baseGame) knockOutLocked(reason string, seats ...int) {
+	b.eliminateLocked(reason, seats...)
+	if b.status == StatusFin {
+		return
+	}
+	b.takeback, b.takebackAccepted = "", nil
+	for _, seat := range seats {
+		b.history = append(b.history, PlayedMove{
+			Seat:   seat,
+			Player: b.players[seat],
+			Move:   GameMove{Out: reason},
+			At:     time.Now(),
+		})
+	}
+	b.skipStuckLocked()
+}
+
+// knockOut replays a player leaving the game, as found in the history.
+func (b *baseGame) knockOut(player, reason string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	idx := slices.Index(b.players, player)
+	if idx == -1 {
+		return errors.New("player not in game")
+	}
+	if b.status == StatusInProgress && !b.out[idx] {
+		b.knockOutLocked(reason, idx)
+		b.notifyLocked(UpdateAction)
+	}
+	return nil
+}
+
+// teamsLeftLocked returns a seat from each team that still has a player in.
+func (b *baseGame) teamsLeftLocked() []int {
+	var left []int
+	seen := map[int]bool{}
+	for seat := range b.players {
+		if team := b.teamLocked(seat); !b.out[seat] && !seen[team] {
+			seen[team] = true
+			left = append(left, seat)
+		}
+	}
+	return left
+}
+
+// seatsInLocked counts the seats that have not been knocked out.
+func (b *baseGame) seatsInLocked() int {
+	in := 0
+	for seat := range b.players {
+		if !b.out[seat] {
+			in++
+		}
+	}
+	return in
+}
+
+func (b *baseGame) teamLocked(seat int) int {
+	if b.teams == nil {
+		return seat
+	}
+	return b.teams[seat]
+}
+
+// finishLocked ends the game. The win goes to winner's whole team, and to no
+// one if winner is empty.
+func (b *baseGame) finishLocked(winner, reason string) {
+	if b.clock != nil {
+		b.clock.pause(b.turn, time.Now())
+	}
+	b.status = StatusFin
+	b.winners = nil
+	if seat := slices.Index(b.players, winner); seat != -1 {
+		for other, player := range b.players {
+			if b.teamLocked(other) == b.teamLocked(seat) {
+				b.winners = append(b.winners, player)
+			}
+		}
+	}
+	b.winner = ""
+	if len(b.winners) == 1 {
+		b.winner = b.winners[0]
+	}
+	b.reason = reason
+	b.drawOffer, b.drawAccepted = "", nil
+	b.takeback, b.takebackAccepted = "", nil
+	clear(b.disconnects)
+	b.endedAt = time.Now()
+}
+
 func (b *baseGame) getValidMovesLocked() []GameMove {
 	return nil
 }
 
+func (b *baseGame) undoLocked(GameMove) error {
+	return errors.New("takebacks not supported for " + b.gameName)
+}
+
+// undoableLocked reports how many of the last moves undoLocked can take
+// back, or -1 if the game has no takebacks.
+func (b *baseGame) undoableLocked() int {
+	return -1
+}
+
+// clockPausedLocked reports whether the game has stopped its clock for a
+// phase of its own, which getting everyone back must not end.
+func (b *baseGame) clockPausedLocked() bool {
+	return false
+}
+
+func (b *baseGame) startClockLocked(now time.Time) {
+	if b.clock != nil && !b.self.clockPausedLocked() {
+		b.clock.start(now)
+	}
+}
+
+// GetState returns the whole state, hidden parts included. Clients get
+// theirs from StateFor.
 func (b *baseGame) GetState() *GameState {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
@@ -192,15 +660,154 @@ func (b *baseGame) GetState() *GameState {
 	return b.stateLocked()
 }
 
+// StateFor returns the state as viewer sees it; spectators are "".
+func (b *baseGame) StateFor(viewer string) *GameState {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.viewLocked(viewer)
+}
+
+// viewLocked hides what viewer may not see: only the player to move gets the
+// valid moves, unless anyone may move, and hidden games only show them their
+// own side of the board.
+func (b *baseGame) viewLocked(viewer string) *GameState {
+	state := b.stateLocked()
+	if h, ok := b.self.(hidden); ok {
+		state.Board = h.boardForLocked(viewer)
+	}
+	if s, ok := b.self.(simultaneous); ok && s.anyoneMovesLocked() && slices.Contains(b.players, viewer) {
+		return state
+	}
+	if b.turn >= len(b.players) || b.players[b.turn] != viewer {
+		state.ValidMoves = []GameMove{}
+	}
+	return state
+}
+
 func (b *baseGame) stateLocked() *GameState {
-	return &GameState{
-		GameName:   b.gameName,
-		Players:    b.players,
-		Turn:       b.turn,
-		Board:      b.self.getBoardLocked(),
-		ValidMoves: b.self.getValidMovesLocked(),
-		Status:     b.status,
-		Winner:     b.winner,
+	var clockState *ClockState
+	if b.clock != nil {
+		clockState = b.clock.state(b.turn, time.Now())
+	}
+	state := &GameState{
+		ID:               b.id,
+		GameName:         b.gameName,
+		Players:          b.players,
+		Seats:            b.numPlayers,
+		Teams:            b.teams,
+		Spectators:       b.spectators,
+		Turn:             b.turn,
+		Board:            b.self.getBoardLocked(),
+		ValidMoves:       b.self.getValidMovesLocked(),
+		Status:           b.status,
+		Winner:           b.winner,
+		Winners:          b.winners,
+		Reason:           b.reason,
+		Clock:            clockState,
+		DrawOffer:        b.drawOffer,
+		DrawAccepted:     b.drawAccepted,
+		Takeback:         b.takeback,
+		TakebackAccepted: b.takebackAccepted,
+	}
+	if b.status != StatusWaiting {
+		for seat, out := range b.out {
+			if out {
+				state.Eliminated = append(state.Eliminated, b.players[seat])
+			}
+		}
+	}
+	if n, ok := b.self.(notated); ok {
+		state.Notation = n.notationLocked()
+	}
+	if s, ok := b.self.(scored); ok {
+		state.Scoring = s.scoringLocked()
+	}
+	return state
+}
+
+// notifyLocked sends the current state out, attaching the game record the
+// first time a started game is seen finished.
+func (b *baseGame) notifyLocked(action GameAction) {
+	update := GameUpdate{
+		State:  b.stateLocked(),
+		Action: action,
+	}
+	if b.status == StatusFin && !b.recorded && !b.startedAt.IsZero() {
+		b.recorded = true
+		update.Record = b.recordLocked()
+	}
+	b.notify(update)
+}
+
+// Record returns the game as played so far, or nil if it has not started.
+func (b *baseGame) Record() *Record {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.startedAt.IsZero() {
+		return nil
+	}
+	return b.recordLocked()
+}
+
+func (b *baseGame) recordLocked() *Record {
+	return &Record{
+		ID:        b.id,
+		GameName:  b.gameName,
+		Players:   b.seats,
+		Winner:    b.winner,
+		Winners:   b.winners,
+		Reason:    b.reason,
+		Options:   b.opts,
+		StartedAt: b.startedAt,
+		EndedAt:   b.endedAt,
+		Moves:     slices.Clone(b.history),
+	}
+}
+
+// Snapshot returns what the game needs to come back after a restart, or nil
+// unless it is running.
+func (b *baseGame) Snapshot() *Snapshot {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.snapshotLocked()
+}
+
+func (b *baseGame) snapshotLocked() *Snapshot {
+	if b.startedAt.IsZero() || b.status == StatusFin {
+		return nil
+	}
+	snap := &Snapshot{Record: *b.recordLocked()}
+	if b.clock != nil {
+		snap.Clock, snap.MoveUsed = b.clock.snapshot(b.turn, time.Now())
+	}
+	return snap
+}
+
+// restore finishes bringing a game back from snap once its moves have been
+// played again. Everyone still in has DisconnectTimeout to rejoin, and
+// updates go to updator from now on.
+func (b *baseGame) restore(snap *Snapshot, updator func(GameUpdate)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.id = snap.ID
+	b.startedAt = snap.StartedAt
+	b.history = slices.Clone(snap.Moves)
+	b.notify = updator
+	if b.status == StatusFin {
+		return
+	}
+	if b.clock != nil && snap.Clock != nil {
+		b.clock.restore(snap.Clock, snap.MoveUsed)
+	}
+	b.status = StatusDisconnected
+	now := time.Now()
+	for seat, player := range b.players {
+		if !b.out[seat] {
+			b.disconnects[player] = now
+		}
 	}
 }
 
@@ -224,47 +831,37 @@ func (b *baseGame) updateLoop() {
 
 	if b.status == StatusFin {
 		if time.Since(b.endedAt) > CleanupDelay {
-			b.notify(GameUpdate{
-				State:  b.stateLocked(),
-				Action: DeleteAction,
-			})
+			b.notifyLocked(DeleteAction)
 			b.Stop()
 		}
 		return
 	}
 
-	disconnections := []string{}
+	if b.status == StatusInProgress && b.clock != nil && b.clock.flagged(b.turn, time.Now()) {
+		b.self.handleFlagLocked()
+		return
+	}
+
+	gone := []int{}
 	for player, disconnectTime := range b.disconnects {
 		if time.Since(disconnectTime) > DisconnectTimeout {
-			disconnections = append(disconnections, player)
+			gone = append(gone, slices.Index(b.players, player))
 		}
 	}
-	if len(disconnections) > 0 {
-		for _, player := range disconnections {
-			delete(b.disconnects, player)
-			idx := slices.Index(b.players, player)
-			if idx != -1 {
-				b.players = slices.Delete(b.players, idx, idx+1)
-			}
-		}
-		b.self.handleDisconnectLocked()
+	if len(gone) > 0 {
+		slices.Sort(gone)
+		b.self.handleDisconnectLocked(gone)
 	}
 }
 
-func (b *baseGame) handleDisconnectLocked() {
-	// just stop game if player doesn't come back.
-	b.status = StatusFin
-	b.endedAt = time.Now()
-	if len(b.players) == 0 {
-		b.notify(GameUpdate{
-			State:  b.stateLocked(),
-			Action: DeleteAction,
-		})
-		b.Stop()
-	} else {
-		b.notify(GameUpdate{
-			State:  b.stateLocked(),
-			Action: UpdateAction,
-		})
-	}
+// handleDisconnectLocked knocks out the players at seats who did not come
+// back in time. If no team is left the game ends without a winner.
+func (b *baseGame) handleDisconnectLocked(seats []int) {
+	b.knockOutLocked(ReasonAbandoned, seats...)
+	b.notifyLocked(UpdateAction)
+}
+
+func (b *baseGame) handleFlagLocked() {
+	b.knockOutLocked(ReasonTimeout, b.turn)
+	b.notifyLocked(UpdateAction)
 }
@@ -0,0 +1,386 @@
+package game
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+const (
+	goKomi = 7.5
+	goDead = 10 // added to a stone's code while it is marked dead
+
+	goResume = "resume" // Change of a marking move that goes back to play
+)
+
+// goGame is Go with area scoring; black (1) sits at seat 0 and moves first.
+// Two passes in a row start the marking phase, where a move on a stone
+// toggles its group dead or alive and a pass says the player is done. The
+// game is scored once both are done with the same marking; a player who
+// disagrees can resume play instead. Either player may mark, out of turn.
+type goGame struct {
+	baseGame
+	size      int
+	board     [][]int
+	passes    int
+	snapshots []goSnapshot // board after every move since play last began, for ko and takebacks
+	marking   bool
+	marks     int // marking moves in the history, which cannot be taken back
+	dead      map[Position]bool
+	done      [2]bool
+	final     *Scoring
+}
+
+type goSnapshot struct {
+	board  [][]int
+	passes int
+}
+
+var goDirs = [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+
+func newGo() Factory {
+	return func(opts *Options, updator func(GameUpdate)) (Game, error) {
+		size := opts.BoardSize
+		if size == 0 {
+			size = 19
+		}
+		if size != 9 && size != 13 && size != 19 {
+			return nil, errors.New("board size must be 9, 13 or 19")
+		}
+		game := &goGame{
+			baseGame: newBase(2, "go", opts, updator),
+			size:     size,
+			board:    make([][]int, size),
+			dead:     make(map[Position]bool),
+		}
+		for i := range game.board {
+			game.board[i] = make([]int, size)
+		}
+		game.snapshots = []goSnapshot{{board: cloneBoard(game.board)}}
+		game.self = game
+		return game, nil
+	}
+}
+
+func (g *goGame) getBoardLocked() any {
+	board := cloneBoard(g.board)
+	for p := range g.dead {
+		board[p.Row][p.Col] += goDead
+	}
+	return board
+}
+
+func (g *goGame) getValidMovesLocked() []GameMove {
+	validMoves := []GameMove{}
+	if g.status != StatusInProgress {
+		return validMoves
+	}
+	for row := range g.size {
+		for col := range g.size {
+			at := Position{Row: row, Col: col}
+			if g.marking {
+				if g.board[row][col] != 0 {
+					validMoves = append(validMoves, GameMove{To: at})
+				}
+			} else if _, err := g.captureLocked(g.turn, at); err == nil {
+				validMoves = append(validMoves, GameMove{To: at})
+			}
+		}
+	}
+	if g.marking {
+		validMoves = append(validMoves, GameMove{Change: goResume})
+	}
+	return append(validMoves, GameMove{Pass: true})
+}
+
+func (g *goGame) anyoneMovesLocked() bool {
+	return g.marking
+}
+
+func (g *goGame) Move(sender string, mv *GameMove) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.marking {
+		return g.markLocked(sender, mv)
+	}
+	idx, err := g.checkTurnLocked(sender)
+	if err != nil {
+		return err
+	}
+	if mv.Change == goResume {
+		return errors.New("play has not stopped")
+	}
+
+	if mv.Pass {
+		g.passes++
+	} else {
+		captured, err := g.captureLocked(idx, mv.To)
+		if err != nil {
+			return err
+		}
+		g.board[mv.To.Row][mv.To.Col] = idx + 1
+		for _, p := range captured {
+			g.board[p.Row][p.Col] = 0
+		}
+		g.passes = 0
+	}
+	g.snapshots = append(g.snapshots, goSnapshot{board: cloneBoard(g.board), passes: g.passes})
+	g.endTurnLocked(mv)
+	if g.passes == 2 {
+		g.marking = true
+		if g.clock != nil {
+			g.clock.pause(g.turn, time.Now())
+		}
+	}
+	g.notifyLocked(UpdateAction)
+	return nil
+}
+
+func (g *goGame) markLocked(sender string, mv *GameMove) error {
+	seat, err := g.seatLocked(sender)
+	if err != nil {
+		return err
+	}
+	switch {
+	case mv.Change == goResume:
+		g.resumeLocked()
+	case mv.Pass:
+		g.done[seat] = true
+	default:
+		if !g.onBoard(mv.To) || g.board[mv.To.Row][mv.To.Col] == 0 {
+			return errors.New("no stone there")
+		}
+		stones, _ := g.groupLocked(g.board, mv.To)
+		dead := !g.dead[mv.To]
+		for _, p := range stones {
+			if dead {
+				g.dead[p] = true
+			} else {
+				delete(g.dead, p)
+			}
+		}
+		g.done = [2]bool{}
+	}
+
+	// Marking moves go in the history without passing the turn, so that
+	// records, replays and restores go through the marking too.
+	g.history = append(g.history, PlayedMove{Seat: seat, Player: sender, Move: *mv, At: time.Now()})
+	if g.marking {
+		g.marks++
+		if g.done[0] && g.done[1] {
+			g.finishByScoreLocked()
+		}
+	}
+	g.notifyLocked(UpdateAction)
+	return nil
+}
+
+// resumeLocked goes back to play from the marking phase, with the player
+// who passed first to move. Moves from before cannot be taken back.
+func (g *goGame) resumeLocked() {
+	g.marking = false
+	g.marks = 0
+	g.dead = make(map[Position]bool)
+	g.done = [2]bool{}
+	g.passes = 0
+	g.snapshots = []goSnapshot{{board: cloneBoard(g.board)}}
+	if g.clock != nil {
+		g.clock.restart(time.Now())
+	}
+}
+
+// The clocks stand still while the players mark dead stones.
+func (g *goGame) clockPausedLocked() bool {
+	return g.marking
+}
+
+func (g *goGame) undoableLocked() int {
+	if g.marks > 0 {
+		return 0
+	}
+	return len(g.snapshots) - 1
+}
+
+// undoLocked goes back to the board before the last move, leaving the
+// marking phase if that move was the second pass.
+func (g *goGame) undoLocked(GameMove) error {
+	if len(g.snapshots) < 2 {
+		return errors.New("no move to take back")
+	}
+	g.snapshots = g.snapshots[:len(g.snapshots)-1]
+	last := g.snapshots[len(g.snapshots)-1]
+	g.board = cloneBoard(last.board)
+	g.passes = last.passes
+	g.marking = false
+	g.dead = make(map[Position]bool)
+	g.done = [2]bool{}
+	return nil
+}
+
+// captureLocked checks that seat may play at and returns the stones that
+// would be captured. Suicide and immediately retaking a ko are not allowed.
+func (g *goGame) captureLocked(seat int, at Position) ([]Position, error) {
+	if !g.onBoard(at) {
+		return nil, errors.New("invalid move")
+	}
+	if g.board[at.Row][at.Col] != 0 {
+		return nil, errors.New("point already taken")
+	}
+	g.board[at.Row][at.Col] = seat + 1
+	defer func() { g.board[at.Row][at.Col] = 0 }()
+
+	var captured []Position
+	for _, d := range goDirs {
+		next := Position{Row: at.Row + d[0], Col: at.Col + d[1]}
+		if !g.onBoard(next) || g.board[next.Row][next.Col] != 2-seat || slices.Contains(captured, next) {
+			continue
+		}
+		if stones, liberties := g.groupLocked(g.board, next); liberties == 0 {
+			captured = append(captured, stones...)
+		}
+	}
+	if _, liberties := g.groupLocked(g.board, at); liberties == 0 && len(captured) == 0 {
+		return nil, errors.New("suicide is not allowed")
+	}
+	if len(captured) == 1 && g.repeatsLocked(captured[0]) {
+		return nil, errors.New("ko: cannot retake immediately")
+	}
+	return captured, nil
+}
+
+// repeatsLocked reports whether the board, with the stone just tried and
+// taken off the stone at captured, is the one before the opponent's move.
+func (g *goGame) repeatsLocked(captured Position) bool {
+	if len(g.snapshots) < 2 {
+		return false
+	}
+	prev := g.snapshots[len(g.snapshots)-2].board
+	for row := range g.size {
+		for col := range g.size {
+			cell := g.board[row][col]
+			if row == captured.Row && col == captured.Col {
+				cell = 0
+			}
+			if prev[row][col] != cell {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// groupLocked returns the stones connected to at and how many liberties
+// they share.
+func (g *goGame) groupLocked(board [][]int, at Position) ([]Position, int) {
+	color := board[at.Row][at.Col]
+	stones := []Position{at}
+	seen := make([]bool, g.size*g.size)
+	seen[at.Row*g.size+at.Col] = true
+	liberties := 0
+	for i := 0; i < len(stones); i++ {
+		for _, d := range goDirs {
+			next := Position{Row: stones[i].Row + d[0], Col: stones[i].Col + d[1]}
+			if !g.onBoard(next) || seen[next.Row*g.size+next.Col] {
+				continue
+			}
+			switch board[next.Row][next.Col] {
+			case 0:
+				seen[next.Row*g.size+next.Col] = true
+				liberties++
+			case color:
+				seen[next.Row*g.size+next.Col] = true
+				stones = append(stones, next)
+			}
+		}
+	}
+	return stones, liberties
+}
+
+// scoresLocked counts each side's living stones plus the empty points only
+// they surround, with dead stones taken off first. White gets komi.
+func (g *goGame) scoresLocked() [2]float64 {
+	board := cloneBoard(g.board)
+	for p := range g.dead {
+		board[p.Row][p.Col] = 0
+	}
+	scores := [2]float64{0, goKomi}
+	seen := map[Position]bool{}
+	for row := range g.size {
+		for col := range g.size {
+			at := Position{Row: row, Col: col}
+			if color := board[row][col]; color != 0 {
+				scores[color-1]++
+				continue
+			}
+			if seen[at] {
+				continue
+			}
+			region := []Position{at}
+			seen[at] = true
+			borders := map[int]bool{}
+			for i := 0; i < len(region); i++ {
+				for _, d := range goDirs {
+					next := Position{Row: region[i].Row + d[0], Col: region[i].Col + d[1]}
+					if !g.onBoard(next) {
+						continue
+					}
+					if color := board[next.Row][next.Col]; color != 0 {
+						borders[color] = true
+					} else if !seen[next] {
+						seen[next] = true
+						region = append(region, next)
+					}
+				}
+			}
+			if len(borders) == 1 {
+				for color := range borders {
+					scores[color-1] += float64(len(region))
+				}
+			}
+		}
+	}
+	return scores
+}
+
+func (g *goGame) scoringLocked() *Scoring {
+	if !g.marking {
+		return g.final
+	}
+	scores := g.scoresLocked()
+	scoring := &Scoring{
+		Scores:  map[string]float64{g.players[0]: scores[0], g.players[1]: scores[1]},
+		Marking: true,
+	}
+	for seat, done := range g.done {
+		if done {
+			scoring.Done = append(scoring.Done, g.players[seat])
+		}
+	}
+	return scoring
+}
+
+func (g *goGame) finishByScoreLocked() {
+	scores := g.scoresLocked()
+	g.final = &Scoring{
+		Scores: map[string]float64{g.players[0]: scores[0], g.players[1]: scores[1]},
+	}
+	g.marking = false
+	winner := g.players[0]
+	if scores[1] > scores[0] {
+		winner = g.players[1]
+	}
+	g.finishLocked(winner, ReasonScore)
+}
+
+func (g *goGame) onBoard(p Position) bool {
+	return p.Row >= 0 && p.Row < g.size && p.Col >= 0 && p.Col < g.size
+}
+
+func cloneBoard(board [][]int) [][]int {
+	clone := make([][]int, len(board))
+	for i, row := range board {
+		clone[i] = slices.Clone(row)
+	}
+	return clone
+}
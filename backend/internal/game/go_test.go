@@ -0,0 +1,99 @@
+package game
+
+import "testing"
+
+func TestGoMarkingIsRecorded(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterAll()
+	g, err := registry.Create("go", &Options{BoardSize: 9}, func(GameUpdate) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Stop()
+	for _, player := range []string{"black", "white"} {
+		if err := g.Join(player); err != nil {
+			t.Fatal(err)
+		}
+	}
+	move := func(player string, mv GameMove) {
+		t.Helper()
+		if err := g.Move(player, &mv); err != nil {
+			t.Fatalf("%s %+v: %v", player, mv, err)
+		}
+	}
+	at := func(row, col int) GameMove { return GameMove{To: Position{Row: row, Col: col}} }
+	pass := GameMove{Pass: true}
+
+	move("black", at(0, 0))
+	move("white", at(8, 8))
+	if err := g.Move("black", &GameMove{Change: goResume}); err == nil {
+		t.Error("resumed a game still in play")
+	}
+	move("black", pass)
+	move("white", pass)
+	for _, player := range []string{"black", "white"} {
+		if len(g.StateFor(player).ValidMoves) == 0 {
+			t.Errorf("%s gets no marking moves", player)
+		}
+	}
+	if len(g.StateFor("").ValidMoves) != 0 {
+		t.Error("spectators get marking moves")
+	}
+
+	move("white", at(0, 0))
+	move("black", GameMove{Change: goResume})
+	if state := g.GetState(); state.Scoring != nil || state.Players[state.Turn] != "black" {
+		t.Fatalf("resumed with %s to move and scoring %+v", state.Players[state.Turn], state.Scoring)
+	}
+	move("black", at(4, 4))
+	move("white", pass)
+	move("black", pass)
+	move("black", pass)
+	move("white", pass)
+
+	state := g.GetState()
+	if state.Status != StatusFin || state.Winner != "white" {
+		t.Fatalf("finished as %s, won by %q", state.Status, state.Winner)
+	}
+	states, err := registry.Replay(g.Record())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if marked := states[5].Board.([][]int)[0][0]; marked != 1+goDead {
+		t.Errorf("replay lost the marking: (0, 0) is %d", marked)
+	}
+	last := states[len(states)-1]
+	if last.Reason != ReasonScore || last.Scoring.Scores["black"] != state.Scoring.Scores["black"] {
+		t.Errorf("replay ended with %q and %+v, want %+v", last.Reason, last.Scoring, state.Scoring)
+	}
+}
+
+func TestGoClockStaysPausedWhileMarking(t *testing.T) {
+	g, err := newGo()(&Options{BoardSize: 9, TimeControl: &TimeControl{Initial: 60}}, func(GameUpdate) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Stop()
+	for _, player := range []string{"black", "white"} {
+		if err := g.Join(player); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, player := range []string{"black", "white"} {
+		if err := g.Move(player, &GameMove{Pass: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if g.GetState().Clock.Running {
+		t.Fatal("clock runs after both passed")
+	}
+
+	g.Leave("white", false)
+	if status := g.GetState().Status; status != StatusDisconnected {
+		t.Fatalf("game %s after white lost the connection", status)
+	}
+	g.Rejoin("white")
+	if state := g.GetState(); state.Status != StatusInProgress || state.Clock.Running {
+		t.Errorf("after white came back the game is %s with the clock running %v", state.Status, state.Clock.Running)
+	}
+}
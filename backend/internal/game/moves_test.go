@@ -0,0 +1,233 @@
+package game
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+// moveCase describes how to probe one registered game: which moves to try
+// beyond the listed ones, and when two moves count as the same.
+type moveCase struct {
+	opts     *Options
+	universe func() []GameMove
+	key      func(GameMove) string
+	maxPlies int
+	games    int // random games to play
+}
+
+func gridUniverse(rows, cols int) func() []GameMove {
+	return func() []GameMove {
+		moves := []GameMove{}
+		for row := range rows {
+			for col := range cols {
+				moves = append(moves, GameMove{To: Position{Row: row, Col: col}})
+			}
+		}
+		return moves
+	}
+}
+
+func fromToUniverse() []GameMove {
+	moves := []GameMove{}
+	for from := range 64 {
+		for to := range 64 {
+			moves = append(moves, GameMove{
+				From: Position{Row: from / 8, Col: from % 8},
+				To:   Position{Row: to / 8, Col: to % 8},
+			})
+		}
+	}
+	return moves
+}
+
+func chessUniverse() []GameMove {
+	moves := []GameMove{}
+	for _, mv := range fromToUniverse() {
+		moves = append(moves, mv)
+		if mv.To.Row == 0 || mv.To.Row == 7 {
+			for _, change := range []string{"q", "r", "b", "n"} {
+				mv.Change = change
+				moves = append(moves, mv)
+			}
+		}
+	}
+	return moves
+}
+
+func sameMove(mv GameMove) string { return fmt.Sprint(mv) }
+
+func fromTo(mv GameMove) string { return fmt.Sprint(mv.From, mv.To) }
+
+var moveCases = map[string][]moveCase{
+	"tictactoe": {{universe: gridUniverse(3, 3), key: sameMove, maxPlies: 9, games: 3}},
+	"connect4": {{
+		universe: gridUniverse(1, 7),
+		// Move only reads the column; clients send row 0.
+		key:      func(mv GameMove) string { return fmt.Sprint(mv.To.Col) },
+		maxPlies: 42,
+		games:    2,
+	}},
+	"chess": {
+		{universe: chessUniverse, key: sameMove, maxPlies: 12, games: 1},
+		{
+			opts:     &Options{FEN: "4k3/1P6/8/8/8/8/6p1/4K3 w - - 0 1"},
+			universe: chessUniverse,
+			key:      sameMove,
+			maxPlies: 4,
+			games:    1,
+		},
+	},
+	"othello": {{universe: gridUniverse(8, 8), key: sameMove, maxPlies: 60, games: 2}},
+	"go": {{
+		opts:     &Options{BoardSize: 9},
+		universe: func() []GameMove { return append(gridUniverse(9, 9)(), GameMove{Pass: true}) },
+		key:      sameMove,
+		maxPlies: 20,
+		games:    2,
+	}},
+	"checkers": {{
+		universe: fromToUniverse,
+		// Path may be left out when only one jump leads from From to To.
+		key:      fromTo,
+		maxPlies: 50,
+		games:    2,
+	}},
+	"battleship": {{
+		universe: func() []GameMove {
+			moves := []GameMove{}
+			for _, mv := range gridUniverse(battleshipSize, battleshipSize)() {
+				for _, change := range []string{"", "h", "v"} {
+					mv.Change = change
+					moves = append(moves, mv)
+				}
+			}
+			return moves
+		},
+		key:      sameMove,
+		maxPlies: 40,
+		games:    1,
+	}},
+	"trails": {
+		{universe: gridUniverse(trailsSize, trailsSize), key: sameMove, maxPlies: 200, games: 2},
+		{
+			opts:     &Options{Players: 3},
+			universe: gridUniverse(trailsSize, trailsSize),
+			key:      sameMove,
+			maxPlies: 200,
+			games:    1,
+		},
+		{
+			opts:     &Options{Players: 6, Teams: 3},
+			universe: gridUniverse(trailsSize, trailsSize),
+			key:      sameMove,
+			maxPlies: 200,
+			games:    1,
+		},
+	},
+}
+
+// ruleCase probes a game loaded from a rule file.
+func ruleCase(rules *Rules) moveCase {
+	tc := moveCase{
+		universe: func() []GameMove {
+			moves := gridUniverse(rules.Rows, rules.Cols)()
+			return append(moves, GameMove{Pass: true})
+		},
+		key:      sameMove,
+		maxPlies: 20,
+		games:    1,
+	}
+	if rules.Gravity {
+		tc.universe = gridUniverse(1, rules.Cols)
+		tc.key = func(mv GameMove) string { return fmt.Sprint(mv.To.Col, mv.Pass) }
+	}
+	return tc
+}
+
+func TestValidMoves(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterAll()
+	if err := registry.LoadRules("../../games"); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, info := range registry.games {
+		cases, ok := moveCases[name]
+		if !ok && info.Rules != nil {
+			cases, ok = []moveCase{ruleCase(info.Rules)}, true
+		}
+		if !ok {
+			t.Errorf("%s: no valid-move test case", name)
+			continue
+		}
+		for i, tc := range cases {
+			rng := rand.New(rand.NewPCG(uint64(i), 42))
+			for range tc.games {
+				checkValidMoves(t, registry, name, tc, rng)
+			}
+		}
+	}
+}
+
+// checkValidMoves plays a random game, checking at every position that each
+// listed move is accepted and every other move in the universe is rejected.
+func checkValidMoves(t *testing.T, registry *Registry, name string, tc moveCase, rng *rand.Rand) {
+	t.Helper()
+	start := func(played []PlayedMove) Game {
+		g, err := registry.Create(name, tc.opts, func(GameUpdate) {})
+		if err != nil {
+			t.Fatalf("%s: create: %v", name, err)
+		}
+		for i := 0; g.GetState().Status == StatusWaiting; i++ {
+			if err := g.Join(fmt.Sprint("player", i)); err != nil {
+				t.Fatalf("%s: join: %v", name, err)
+			}
+		}
+		for _, pm := range played {
+			if err := g.Move(pm.Player, &pm.Move); err != nil {
+				t.Fatalf("%s: replaying %+v: %v", name, pm.Move, err)
+			}
+		}
+		return g
+	}
+
+	played := []PlayedMove{}
+	g := start(played)
+	for ply := 0; ; ply++ {
+		state := g.GetState()
+		if state.Status != StatusInProgress || ply == tc.maxPlies {
+			if state.Status == StatusFin && len(state.ValidMoves) != 0 {
+				t.Errorf("%s: finished game lists %d moves", name, len(state.ValidMoves))
+			}
+			return
+		}
+		if len(state.ValidMoves) == 0 {
+			t.Fatalf("%s: no valid moves listed after %v", name, played)
+		}
+		mover := state.Players[state.Turn]
+
+		listed := make(map[string]bool, len(state.ValidMoves))
+		for _, mv := range state.ValidMoves {
+			listed[tc.key(mv)] = true
+			probe := start(played)
+			if err := probe.Move(mover, &mv); err != nil {
+				t.Errorf("%s: listed move %+v rejected after %v: %v", name, mv, played, err)
+			}
+		}
+		for _, mv := range tc.universe() {
+			if listed[tc.key(mv)] {
+				continue
+			}
+			if err := g.Move(mover, &mv); err == nil {
+				t.Fatalf("%s: unlisted move %+v accepted after %v", name, mv, played)
+			}
+		}
+
+		next := state.ValidMoves[rng.IntN(len(state.ValidMoves))]
+		if err := g.Move(mover, &next); err != nil {
+			t.Fatalf("%s: move %+v: %v", name, next, err)
+		}
+		played = append(played, PlayedMove{Player: mover, Move: next})
+	}
+}
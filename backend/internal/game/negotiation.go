@@ -0,0 +1,203 @@
+package game
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+func (b *baseGame) seatLocked(player string) (int, error) {
+	if b.status != StatusInProgress {
+		return -1, errors.New("game not in progress")
+	}
+	idx := slices.Index(b.players, player)
+	if idx == -1 {
+		return -1, errors.New("player not in game")
+	}
+	if b.out[idx] {
+		return -1, errors.New("you are out of the game")
+	}
+	return idx, nil
+}
+
+func (b *baseGame) Resign(player string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	idx, err := b.seatLocked(player)
+	if err != nil {
+		return err
+	}
+	b.knockOutLocked(ReasonResignation, idx)
+	b.notifyLocked(UpdateAction)
+	return nil
+}
+
+func (b *baseGame) OfferDraw(player string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.seatLocked(player); err != nil {
+		return err
+	}
+	if b.drawOffer != "" {
+		return errors.New("a draw is already on offer")
+	}
+	b.drawOffer = player
+	b.notifyLocked(UpdateAction)
+	return nil
+}
+
+func (b *baseGame) AcceptDraw(player string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	agreed, err := b.agreeLocked(player, b.drawOffer, &b.drawAccepted)
+	if err != nil {
+		return err
+	}
+	if agreed {
+		b.finishLocked("", ReasonAgreement)
+	}
+	b.notifyLocked(UpdateAction)
+	return nil
+}
+
+func (b *baseGame) DeclineDraw(player string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.checkAnswerLocked(player, b.drawOffer); err != nil {
+		return err
+	}
+	b.drawOffer, b.drawAccepted = "", nil
+	b.notifyLocked(UpdateAction)
+	return nil
+}
+
+func (b *baseGame) RequestTakeback(player string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	idx, err := b.seatLocked(player)
+	if err != nil {
+		return err
+	}
+	if b.takeback != "" {
+		return errors.New("a takeback is already requested")
+	}
+	if _, err := b.takebackLocked(idx); err != nil {
+		return err
+	}
+	b.takeback = player
+	b.notifyLocked(UpdateAction)
+	return nil
+}
+
+// AcceptTakeback rolls the game back to just before the requester's last move,
+// undoing any replies made since, once everyone else still playing agrees.
+func (b *baseGame) AcceptTakeback(player string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.checkAnswerLocked(player, b.takeback); err != nil {
+		return err
+	}
+	moves, err := b.takebackLocked(slices.Index(b.players, b.takeback))
+	if err != nil {
+		return err
+	}
+	agreed, err := b.agreeLocked(player, b.takeback, &b.takebackAccepted)
+	if err != nil {
+		return err
+	}
+	if !agreed {
+		b.notifyLocked(UpdateAction)
+		return nil
+	}
+	if b.clock != nil {
+		b.clock.pause(b.turn, time.Now())
+	}
+	for range moves {
+		last := b.history[len(b.history)-1]
+		if err := b.self.undoLocked(last.Move); err != nil {
+			slog.Error("game: failed to take back move", "error", err, "game", b.gameName)
+			break
+		}
+		b.history = b.history[:len(b.history)-1]
+		b.turn = last.Seat
+	}
+	if b.clock != nil {
+		b.clock.restart(time.Now())
+	}
+	b.takeback, b.takebackAccepted = "", nil
+	b.drawOffer, b.drawAccepted = "", nil
+	b.notifyLocked(UpdateAction)
+	return nil
+}
+
+// takebackLocked counts the moves to undo to get back to just before seat's
+// last move, making sure the game can undo them all.
+func (b *baseGame) takebackLocked(seat int) (int, error) {
+	undoable := b.self.undoableLocked()
+	if undoable < 0 {
+		return 0, errors.New("takebacks not supported for " + b.gameName)
+	}
+	for i, pm := range slices.Backward(b.history) {
+		if pm.Move.Out != "" {
+			return 0, errors.New("cannot take back moves from before a player left")
+		}
+		if pm.Seat == seat {
+			if moves := len(b.history) - i; moves <= undoable {
+				return moves, nil
+			}
+			break
+		}
+	}
+	return 0, errors.New("no move to take back")
+}
+
+func (b *baseGame) DeclineTakeback(player string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.checkAnswerLocked(player, b.takeback); err != nil {
+		return err
+	}
+	b.takeback, b.takebackAccepted = "", nil
+	b.notifyLocked(UpdateAction)
+	return nil
+}
+
+// checkAnswerLocked makes sure player may answer a request made by requester.
+func (b *baseGame) checkAnswerLocked(player, requester string) error {
+	if _, err := b.seatLocked(player); err != nil {
+		return err
+	}
+	if requester == "" {
+		return errors.New("nothing to answer")
+	}
+	if requester == player {
+		return errors.New("cannot answer your own request")
+	}
+	return nil
+}
+
+// agreeLocked counts player in on the request made by requester and reports
+// whether everyone else still playing has now accepted it.
+func (b *baseGame) agreeLocked(player, requester string, accepted *[]string) (bool, error) {
+	if err := b.checkAnswerLocked(player, requester); err != nil {
+		return false, err
+	}
+	if slices.Contains(*accepted, player) {
+		return false, errors.New("already accepted")
+	}
+	*accepted = append(*accepted, player)
+	for seat, p := range b.players {
+		if !b.out[seat] && p != requester && !slices.Contains(*accepted, p) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,123 @@
+package game
+
+import "testing"
+
+// newGameWith creates a registered game and seats players, which starts it.
+func newGameWith(t *testing.T, name string, opts *Options, players ...string) Game {
+	t.Helper()
+	registry := NewRegistry()
+	registry.RegisterAll()
+	g, err := registry.Create(name, opts, func(GameUpdate) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(g.Stop)
+	for _, player := range players {
+		if err := g.Join(player); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if status := g.GetState().Status; status != StatusInProgress {
+		t.Fatalf("%s is %s after seating everyone", name, status)
+	}
+	return g
+}
+
+func TestDrawAgreement(t *testing.T) {
+	g := newGameWith(t, "tictactoe", nil, "a", "b")
+	if err := g.AcceptDraw("b"); err == nil {
+		t.Error("accepted a draw no one offered")
+	}
+	if err := g.OfferDraw("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.OfferDraw("b"); err == nil {
+		t.Error("offered a second draw")
+	}
+	if err := g.AcceptDraw("a"); err == nil {
+		t.Error("accepted own draw offer")
+	}
+	if err := g.AcceptDraw("b"); err != nil {
+		t.Fatal(err)
+	}
+	if state := g.GetState(); state.Status != StatusFin || state.Reason != ReasonAgreement || len(state.Winners) != 0 {
+		t.Errorf("agreed draw left the game %s with reason %q and winners %v", state.Status, state.Reason, state.Winners)
+	}
+}
+
+func TestDrawNeedsEveryone(t *testing.T) {
+	g := newGameWith(t, "trails", &Options{Players: 3}, "a", "b", "c")
+	if err := g.OfferDraw("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AcceptDraw("b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AcceptDraw("b"); err == nil {
+		t.Error("accepted the same draw twice")
+	}
+	if state := g.GetState(); state.Status != StatusInProgress {
+		t.Fatalf("game %s with c yet to answer", state.Status)
+	}
+	if err := g.AcceptDraw("c"); err != nil {
+		t.Fatal(err)
+	}
+	if status := g.GetState().Status; status != StatusFin {
+		t.Errorf("game %s once everyone accepted", status)
+	}
+}
+
+func TestDeclineDraw(t *testing.T) {
+	g := newGameWith(t, "tictactoe", nil, "a", "b")
+	if err := g.OfferDraw("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.DeclineDraw("b"); err != nil {
+		t.Fatal(err)
+	}
+	if state := g.GetState(); state.DrawOffer != "" || state.Status != StatusInProgress {
+		t.Errorf("declined draw left offer %q and the game %s", state.DrawOffer, state.Status)
+	}
+}
+
+func TestTakeback(t *testing.T) {
+	g := newGameWith(t, "tictactoe", nil, "a", "b")
+	if err := g.RequestTakeback("a"); err == nil {
+		t.Error("requested a takeback before moving")
+	}
+	for _, mv := range []struct {
+		player string
+		at     Position
+	}{{"a", Position{Row: 1, Col: 1}}, {"b", Position{Row: 0, Col: 0}}} {
+		if err := g.Move(mv.player, &GameMove{To: mv.at}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.RequestTakeback("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AcceptTakeback("a"); err == nil {
+		t.Error("accepted own takeback")
+	}
+	if err := g.AcceptTakeback("b"); err != nil {
+		t.Fatal(err)
+	}
+
+	state := g.GetState()
+	if state.Players[state.Turn] != "a" || state.Takeback != "" {
+		t.Errorf("after the takeback it is %s's turn with %q asking", state.Players[state.Turn], state.Takeback)
+	}
+	if len(state.ValidMoves) != 9 {
+		t.Errorf("%d moves left after taking both back, want 9", len(state.ValidMoves))
+	}
+}
+
+func TestTakebackUnsupported(t *testing.T) {
+	g := newGameWith(t, "trails", &Options{Players: 3}, "a", "b", "c")
+	if err := g.RequestTakeback("a"); err == nil {
+		t.Error("requested a takeback in a game that cannot undo")
+	}
+	if state := g.GetState(); state.Takeback != "" {
+		t.Errorf("refused takeback left %q asking", state.Takeback)
+	}
+}
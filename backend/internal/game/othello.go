@@ -0,0 +1,147 @@
+package game
+
+import (
+	"errors"
+)
+
+const othelloSize = 8
+
+// othello seats the dark discs (1) at 0 and the light discs (2) at 1. A
+// player without a legal move passes automatically; when neither can move
+// the game ends and the most discs win.
+type othello struct {
+	baseGame
+	board   [othelloSize][othelloSize]int
+	flipped [][]Position // per move, for takebacks
+}
+
+var othelloDirs = [8][2]int{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}}
+
+func newOthello() Factory {
+	return func(opts *Options, updator func(GameUpdate)) (Game, error) {
+		game := &othello{
+			baseGame: newBase(2, "othello", opts, updator),
+		}
+		game.board[3][3], game.board[4][4] = 2, 2
+		game.board[3][4], game.board[4][3] = 1, 1
+		game.self = game
+		return game, nil
+	}
+}
+
+func (o *othello) getBoardLocked() any {
+	return o.board
+}
+
+func (o *othello) getValidMovesLocked() []GameMove {
+	if o.status != StatusInProgress {
+		return []GameMove{}
+	}
+	return o.legalMovesLocked(o.turn)
+}
+
+func (o *othello) Move(sender string, mv *GameMove) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	idx, err := o.checkTurnLocked(sender)
+	if err != nil {
+		return err
+	}
+
+	if mv.To.Row < 0 || mv.To.Row >= othelloSize || mv.To.Col < 0 || mv.To.Col >= othelloSize {
+		return errors.New("invalid move")
+	}
+	if o.board[mv.To.Row][mv.To.Col] != 0 {
+		return errors.New("cell already taken")
+	}
+	flips := o.flipsLocked(idx, mv.To)
+	if len(flips) == 0 {
+		return errors.New("invalid move: must flip at least one disc")
+	}
+	o.board[mv.To.Row][mv.To.Col] = idx + 1
+	for _, p := range flips {
+		o.board[p.Row][p.Col] = idx + 1
+	}
+	o.flipped = append(o.flipped, flips)
+
+	next := 1 - idx
+	if len(o.legalMovesLocked(next)) == 0 {
+		next = idx
+		if len(o.legalMovesLocked(idx)) == 0 {
+			o.finishByCountLocked()
+		}
+	}
+	o.endTurnToLocked(mv, next)
+	o.notifyLocked(UpdateAction)
+	return nil
+}
+
+func (o *othello) undoableLocked() int {
+	return len(o.flipped)
+}
+
+func (o *othello) undoLocked(mv GameMove) error {
+	if len(o.flipped) == 0 {
+		return errors.New("no move to take back")
+	}
+	flips := o.flipped[len(o.flipped)-1]
+	o.flipped = o.flipped[:len(o.flipped)-1]
+
+	opponent := 3 - o.board[mv.To.Row][mv.To.Col]
+	for _, p := range flips {
+		o.board[p.Row][p.Col] = opponent
+	}
+	o.board[mv.To.Row][mv.To.Col] = 0
+	return nil
+}
+
+func (o *othello) legalMovesLocked(seat int) []GameMove {
+	moves := []GameMove{}
+	for row := range othelloSize {
+		for col := range othelloSize {
+			to := Position{Row: row, Col: col}
+			if o.board[row][col] == 0 && len(o.flipsLocked(seat, to)) > 0 {
+				moves = append(moves, GameMove{To: to})
+			}
+		}
+	}
+	return moves
+}
+
+// flipsLocked lists the discs seat would turn over by playing at.
+func (o *othello) flipsLocked(seat int, at Position) []Position {
+	me := seat + 1
+	var flips []Position
+	for _, d := range othelloDirs {
+		var line []Position
+		r, c := at.Row+d[0], at.Col+d[1]
+		for r >= 0 && r < othelloSize && c >= 0 && c < othelloSize && o.board[r][c] == 3-me {
+			line = append(line, Position{Row: r, Col: c})
+			r, c = r+d[0], c+d[1]
+		}
+		if len(line) > 0 && r >= 0 && r < othelloSize && c >= 0 && c < othelloSize && o.board[r][c] == me {
+			flips = append(flips, line...)
+		}
+	}
+	return flips
+}
+
+func (o *othello) finishByCountLocked() {
+	var counts [2]int
+	for _, row := range o.board {
+		for _, cell := range row {
+			if cell != 0 {
+				counts[cell-1]++
+			}
+		}
+	}
+	switch {
+	case counts[0] > counts[1]:
+		o.finishLocked(o.players[0], ReasonScore)
+	case counts[1] > counts[0]:
+		o.finishLocked(o.players[1], ReasonScore)
+	default:
+		o.finishLocked("", ReasonScore)
+	}
+}
@@ -0,0 +1,88 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Replay plays rec back on a fresh game and returns the state before the
+// first move and after each one. Games that ended off the board, e.g. by
+// resignation, have the result set on the last state.
+func (r *Registry) Replay(rec *Record) ([]*GameState, error) {
+	var opts *Options
+	if rec.Options != nil {
+		noClock := *rec.Options
+		noClock.TimeControl = nil
+		opts = &noClock
+	}
+	g, err := r.Create(rec.GameName, opts, func(GameUpdate) {})
+	if err != nil {
+		return nil, err
+	}
+	defer g.Stop()
+
+	for _, player := range rec.Players {
+		if err := g.Join(player); err != nil {
+			return nil, err
+		}
+	}
+	states := []*GameState{replayState(g, rec.ID)}
+	for i, pm := range rec.Moves {
+		if err := replayMove(g, pm); err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+		states = append(states, replayState(g, rec.ID))
+	}
+
+	last := states[len(states)-1]
+	if rec.EndedAt.IsZero() {
+		return states, nil
+	}
+	if last.Status != StatusFin {
+		last.Status = StatusFin
+		last.Winner = rec.Winner
+		last.Winners = rec.Winners
+		last.Reason = rec.Reason
+	}
+	if !slices.Equal(last.Winners, rec.Winners) {
+		return nil, errors.New("replay does not reach the recorded result")
+	}
+	return states, nil
+}
+
+// Restore brings a game back from snap by playing its moves again. It comes
+// back disconnected, waiting for its players to rejoin.
+func (r *Registry) Restore(snap *Snapshot, updator func(GameUpdate)) (Game, error) {
+	g, err := r.Create(snap.GameName, snap.Options, func(GameUpdate) {})
+	if err != nil {
+		return nil, err
+	}
+	for _, player := range snap.Players {
+		if err := g.Join(player); err != nil {
+			return nil, err
+		}
+	}
+	for i, pm := range snap.Moves {
+		if err := replayMove(g, pm); err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+	}
+	g.restore(snap, updator)
+	return g, nil
+}
+
+func replayMove(g Game, pm PlayedMove) error {
+	mv := pm.Move
+	if mv.Out != "" {
+		return g.knockOut(pm.Player, mv.Out)
+	}
+	return g.Move(pm.Player, &mv)
+}
+
+func replayState(g Game, id string) *GameState {
+	state := g.StateFor("")
+	state.ID = id
+	state.Clock = nil
+	return state
+}
@@ -0,0 +1,255 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+)
+
+const (
+	PassNever   = "never"
+	PassAllowed = "allowed" // everyone passing in a row ends the game drawn
+)
+
+// Rules describe a grid game where players take turns putting a piece on
+// the board and try to get enough of them in a line.
+type Rules struct {
+	Name    string `json:"name"`
+	Rows    int    `json:"rows"`
+	Cols    int    `json:"cols"`
+	Players int    `json:"players,omitempty"` // 2 if left out
+	InARow  int    `json:"inARow"`            // pieces in a line that win
+	Gravity bool   `json:"gravity,omitempty"` // pieces drop to the lowest free row
+	Pass    string `json:"pass,omitempty"`    // PassNever if left out
+}
+
+var ruleName = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+func (r *Rules) validate() error {
+	if !ruleName.MatchString(r.Name) {
+		return errors.New("name must be 1-50 lowercase letters, digits, '-' or '_'")
+	}
+	if r.Rows < 1 || r.Rows > 30 || r.Cols < 1 || r.Cols > 30 {
+		return errors.New("rows and cols must be between 1 and 30")
+	}
+	if r.Players == 0 {
+		r.Players = 2
+	}
+	if r.Players < 2 || r.Players > 6 {
+		return errors.New("players must be between 2 and 6")
+	}
+	if r.InARow < 2 || r.InARow > max(r.Rows, r.Cols) {
+		return errors.New("inARow must be at least 2 and fit on the board")
+	}
+	if r.Pass == "" {
+		r.Pass = PassNever
+	}
+	if r.Pass != PassNever && r.Pass != PassAllowed {
+		return fmt.Errorf("pass must be %q or %q", PassNever, PassAllowed)
+	}
+	return nil
+}
+
+// LoadRules registers a game for every .json rule file in dir. A missing
+// dir is not an error; a bad file, or one clashing with another game, is.
+func (r *Registry) LoadRules(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("game: failed to read rules: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("game: failed to read %s: %w", path, err)
+		}
+		rules := &Rules{}
+		if err := json.Unmarshal(data, rules); err != nil {
+			return fmt.Errorf("game: failed to parse %s: %w", path, err)
+		}
+		if err := rules.validate(); err != nil {
+			return fmt.Errorf("game: invalid rules in %s: %w", path, err)
+		}
+		if _, ok := r.games[rules.Name]; ok {
+			return fmt.Errorf("game: %s: game %s already exists", path, rules.Name)
+		}
+		r.games[rules.Name] = GameInfo{Factory: newRuleGame(rules), Seats: rules.Players, Rules: rules}
+	}
+	return nil
+}
+
+// ruleGame plays any game described by Rules.
+type ruleGame struct {
+	baseGame
+	rules  *Rules
+	board  [][]int
+	passes int
+	undo   []int // passes before each move
+}
+
+func newRuleGame(rules *Rules) Factory {
+	return func(opts *Options, updator func(GameUpdate)) (Game, error) {
+		game := &ruleGame{
+			baseGame: newBase(rules.Players, rules.Name, opts, updator),
+			rules:    rules,
+			board:    make([][]int, rules.Rows),
+		}
+		for i := range game.board {
+			game.board[i] = make([]int, rules.Cols)
+		}
+		game.self = game
+		return game, nil
+	}
+}
+
+func (g *ruleGame) getBoardLocked() any {
+	return cloneBoard(g.board)
+}
+
+// getValidMovesLocked lists the free cells, or with gravity one move per open
+// column aimed at where the piece would land.
+func (g *ruleGame) getValidMovesLocked() []GameMove {
+	validMoves := []GameMove{}
+	if g.status != StatusInProgress {
+		return validMoves
+	}
+	if g.rules.Gravity {
+		for col := range g.rules.Cols {
+			if at, err := g.landingLocked(Position{Col: col}); err == nil {
+				validMoves = append(validMoves, GameMove{To: at})
+			}
+		}
+	} else {
+		for row := range g.rules.Rows {
+			for col := range g.rules.Cols {
+				if g.board[row][col] == 0 {
+					validMoves = append(validMoves, GameMove{To: Position{Row: row, Col: col}})
+				}
+			}
+		}
+	}
+	if g.rules.Pass == PassAllowed {
+		validMoves = append(validMoves, GameMove{Pass: true})
+	}
+	return validMoves
+}
+
+func (g *ruleGame) Move(sender string, mv *GameMove) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	idx, err := g.checkTurnLocked(sender)
+	if err != nil {
+		return err
+	}
+
+	if mv.Pass {
+		if g.rules.Pass != PassAllowed {
+			return errors.New("passing is not allowed")
+		}
+		g.undo = append(g.undo, g.passes)
+		g.passes++
+		if g.passes >= g.seatsInLocked() {
+			g.finishLocked("", ReasonPasses)
+		}
+		g.endTurnLocked(mv)
+		g.notifyLocked(UpdateAction)
+		return nil
+	}
+
+	at, err := g.landingLocked(mv.To)
+	if err != nil {
+		return err
+	}
+	g.board[at.Row][at.Col] = idx + 1
+	g.undo = append(g.undo, g.passes)
+	g.passes = 0
+
+	if g.lineLocked(at) {
+		g.finishLocked(sender, ReasonLine)
+	} else if !slices.ContainsFunc(g.board, func(row []int) bool { return slices.Contains(row, 0) }) {
+		g.finishLocked("", ReasonBoardFull)
+	}
+	g.endTurnLocked(mv)
+	g.notifyLocked(UpdateAction)
+	return nil
+}
+
+// landingLocked returns the cell a piece played at to ends up on; with
+// gravity only the column counts.
+func (g *ruleGame) landingLocked(to Position) (Position, error) {
+	if to.Col < 0 || to.Col >= g.rules.Cols {
+		return Position{}, errors.New("invalid move")
+	}
+	if g.rules.Gravity {
+		for row := g.rules.Rows - 1; row >= 0; row-- {
+			if g.board[row][to.Col] == 0 {
+				return Position{Row: row, Col: to.Col}, nil
+			}
+		}
+		return Position{}, errors.New("column is full")
+	}
+	if to.Row < 0 || to.Row >= g.rules.Rows {
+		return Position{}, errors.New("invalid move")
+	}
+	if g.board[to.Row][to.Col] != 0 {
+		return Position{}, errors.New("cell already taken")
+	}
+	return to, nil
+}
+
+func (g *ruleGame) undoableLocked() int {
+	return len(g.undo)
+}
+
+func (g *ruleGame) undoLocked(mv GameMove) error {
+	if len(g.undo) == 0 {
+		return errors.New("no move to take back")
+	}
+	g.passes = g.undo[len(g.undo)-1]
+	g.undo = g.undo[:len(g.undo)-1]
+	if mv.Pass {
+		return nil
+	}
+	if !g.rules.Gravity {
+		g.board[mv.To.Row][mv.To.Col] = 0
+		return nil
+	}
+	for row := range g.rules.Rows {
+		if g.board[row][mv.To.Col] != 0 {
+			g.board[row][mv.To.Col] = 0
+			return nil
+		}
+	}
+	return errors.New("column is empty")
+}
+
+// lineLocked reports whether the piece at makes a line long enough to win.
+func (g *ruleGame) lineLocked(at Position) bool {
+	piece := g.board[at.Row][at.Col]
+	for _, dir := range [][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}} {
+		count := 1
+		for _, sign := range []int{1, -1} {
+			row, col := at.Row+sign*dir[0], at.Col+sign*dir[1]
+			for row >= 0 && row < g.rules.Rows && col >= 0 && col < g.rules.Cols && g.board[row][col] == piece {
+				count++
+				row, col = row+sign*dir[0], col+sign*dir[1]
+			}
+		}
+		if count >= g.rules.InARow {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package game
+
+import "testing"
+
+func TestPassesBySeatsStillIn(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.LoadRules("../../games"); err != nil {
+		t.Fatal(err)
+	}
+	g, err := registry.Create("tictactoe3p", nil, func(GameUpdate) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(g.Stop)
+	for _, player := range []string{"a", "b", "c"} {
+		if err := g.Join(player); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g.Leave("a", true)
+
+	for range 2 {
+		state := g.GetState()
+		if state.Status != StatusInProgress {
+			t.Fatalf("game %s before both seats left in passed", state.Status)
+		}
+		if err := g.Move(state.Players[state.Turn], &GameMove{Pass: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if state := g.GetState(); state.Status != StatusFin || state.Reason != ReasonPasses {
+		t.Errorf("game %s with reason %q after both seats left in passed", state.Status, state.Reason)
+	}
+}
@@ -2,7 +2,6 @@ package game
 
 import (
 	"fmt"
-	"time"
 )
 
 type ticTacToe struct {
@@ -11,9 +10,9 @@ type ticTacToe struct {
 }
 
 func newTicTacToe() Factory {
-	return func(updator func(GameUpdate)) (Game, error) {
+	return func(opts *Options, updator func(GameUpdate)) (Game, error) {
 		game := &ticTacToe{
-			baseGame: newBase(2, "tictactoe", updator),
+			baseGame: newBase(2, "tictactoe", opts, updator),
 			board:    [3][3]int{},
 		}
 		game.self = game
@@ -25,6 +24,20 @@ func (t *ticTacToe) getBoardLocked() any {
 	return t.board
 }
 
+func (t *ticTacToe) getValidMovesLocked() []GameMove {
+	validMoves := []GameMove{}
+	if t.status == StatusInProgress {
+		for row := range 3 {
+			for col := range 3 {
+				if t.board[row][col] == 0 {
+					validMoves = append(validMoves, GameMove{To: Position{Row: row, Col: col}})
+				}
+			}
+		}
+	}
+	return validMoves
+}
+
 func (t *ticTacToe) Move(sender string, mv *GameMove) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -43,19 +56,22 @@ func (t *ticTacToe) Move(sender string, mv *GameMove) error {
 	t.board[mv.To.Row][mv.To.Col] = idx + 1
 
 	if win := t.checkWin(); win != 0 {
-		t.status = StatusFin
-		t.winner = t.players[win-1]
-		t.endedAt = time.Now()
+		t.finishLocked(t.players[win-1], ReasonLine)
 	} else if t.checkDraw() {
-		t.status = StatusFin
-		t.endedAt = time.Now()
+		t.finishLocked("", ReasonBoardFull)
 	}
 
-	t.turn = 1 - t.turn
-	t.notify(GameUpdate{
-		State:  t.stateLocked(),
-		Action: UpdateAction,
-	})
+	t.endTurnLocked(mv)
+	t.notifyLocked(UpdateAction)
+	return nil
+}
+
+func (t *ticTacToe) undoableLocked() int {
+	return len(t.history)
+}
+
+func (t *ticTacToe) undoLocked(mv GameMove) error {
+	t.board[mv.To.Row][mv.To.Col] = 0
 	return nil
 }
 
@@ -0,0 +1,112 @@
+package game
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	trailsSize    = 11
+	trailsHead    = 10 // added to the cell a player's head is on
+	trailsPlayers = 4  // unless the options say otherwise
+)
+
+// trails is a turn-based light-cycle game for 3 to 6 players. Each turn a
+// player steps their head onto a free neighbouring cell, leaving a wall
+// behind. Anyone with nowhere to go when their turn comes is out, and the
+// last player or team still moving wins.
+type trails struct {
+	baseGame
+	board [trailsSize][trailsSize]int
+	heads []Position
+}
+
+func newTrails() Factory {
+	return func(opts *Options, updator func(GameUpdate)) (Game, error) {
+		players := opts.Players
+		if players == 0 {
+			players = trailsPlayers
+		}
+		if players < 3 || players > 6 {
+			return nil, errors.New("trails is for 3 to 6 players")
+		}
+		game := &trails{
+			baseGame: newBase(players, "trails", opts, updator),
+			heads:    make([]Position, players),
+		}
+		if err := game.splitTeams(opts.Teams); err != nil {
+			return nil, err
+		}
+		// Heads start spread around a circle, so team-mates sit apart.
+		centre, radius := float64(trailsSize/2), float64(trailsSize/2-1)
+		for seat := range game.heads {
+			angle := 2 * math.Pi * float64(seat) / float64(players)
+			head := Position{
+				Row: int(math.Round(centre + radius*math.Sin(angle))),
+				Col: int(math.Round(centre + radius*math.Cos(angle))),
+			}
+			game.heads[seat] = head
+			game.board[head.Row][head.Col] = seat + 1
+		}
+		game.self = game
+		return game, nil
+	}
+}
+
+func (t *trails) getBoardLocked() any {
+	board := t.board
+	for _, head := range t.heads {
+		board[head.Row][head.Col] += trailsHead
+	}
+	return board
+}
+
+func (t *trails) getValidMovesLocked() []GameMove {
+	if t.status != StatusInProgress {
+		return []GameMove{}
+	}
+	return t.legalMovesLocked(t.turn)
+}
+
+func (t *trails) canMoveLocked(seat int) bool {
+	return len(t.legalMovesLocked(seat)) > 0
+}
+
+func (t *trails) Move(sender string, mv *GameMove) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	idx, err := t.checkTurnLocked(sender)
+	if err != nil {
+		return err
+	}
+	head := t.heads[idx]
+	if abs(mv.To.Row-head.Row)+abs(mv.To.Col-head.Col) != 1 || !onTrailsBoard(mv.To) {
+		return errors.New("invalid move: step to a neighbouring cell")
+	}
+	if t.board[mv.To.Row][mv.To.Col] != 0 {
+		return errors.New("cell already taken")
+	}
+	t.board[mv.To.Row][mv.To.Col] = idx + 1
+	t.heads[idx] = mv.To
+
+	t.endTurnLocked(mv)
+	t.notifyLocked(UpdateAction)
+	return nil
+}
+
+func (t *trails) legalMovesLocked(seat int) []GameMove {
+	moves := []GameMove{}
+	head := t.heads[seat]
+	for _, d := range goDirs {
+		to := Position{Row: head.Row + d[0], Col: head.Col + d[1]}
+		if onTrailsBoard(to) && t.board[to.Row][to.Col] == 0 {
+			moves = append(moves, GameMove{To: to})
+		}
+	}
+	return moves
+}
+
+func onTrailsBoard(p Position) bool {
+	return p.Row >= 0 && p.Row < trailsSize && p.Col >= 0 && p.Col < trailsSize
+}
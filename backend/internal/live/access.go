@@ -0,0 +1,115 @@
+package live
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+
+	"gonext/internal/config"
+	"gonext/pkg/jwt/v2"
+)
+
+// Who can enter a room, besides its host and those invited.
+const (
+	accessOpen     = "open"
+	accessPassword = "password"
+	accessInvite   = "invite" // no one else
+)
+
+const maxPasswordLen = 64
+
+type invitePayload struct {
+	Room string `json:"room"`
+	Key  string `json:"key"`            // of the room when invited
+	User string `json:"user,omitempty"` // the only one it lets in, if set
+}
+
+type InvitePayload struct {
+	User string `json:"user,omitempty"` // to invite only them
+}
+
+type inviteManager = jwt.Manager[invitePayload]
+
+// newInviteManager refuses to sign invites with an empty secret, which
+// would let anyone forge them.
+func newInviteManager(cfg *config.WS) (inviteManager, error) {
+	if cfg.InviteSecret == "" {
+		return nil, errors.New("live: JWT_INVITE_SECRET is not set")
+	}
+	return jwt.NewManager(cfg.InviteSecret, cfg.InviteTTL, "gonext", "RoomInvite", invitePayload{})
+}
+
+func hashPassword(key, password string) string {
+	sum := sha256.Sum256([]byte(key + ":" + password))
+	return hex.EncodeToString(sum[:])
+}
+
+func (i *roomInfo) checkPassword(password string) bool {
+	hash := hashPassword(i.Key, password)
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(i.PassHash)) == 1
+}
+
+// admit checks that client may enter room, saying why not if it may not,
+// and returns the room's key, which lets the client back in when it comes
+// back. Clients already in the room here, say from another tab, are let in.
+func (h *hub) admit(room *room, client *client, req *JoinRoomPayload) (string, error) {
+	if room == h.lobby {
+		return "", nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), h.cfg.WriteTimeout)
+	defer cancel()
+	info := h.index.get(ctx, room.name)
+	if banned, err := h.bans.has(ctx, room.name, info, client.ID); err != nil {
+		slog.Error("hub: failed to check bans", "error", err, "room", room.name)
+		return "", errors.New("cannot check the room's bans, please try again later")
+	} else if banned {
+		return "", errors.New("you are banned from the room")
+	}
+	if info == nil {
+		return "", nil
+	}
+	switch {
+	case room.has(client.ID), info.Host == client.ID:
+		return info.Key, nil
+	case info.full():
+		return "", errors.New("the room is full")
+	case req.key != "" && req.key == info.Key:
+		return info.Key, nil
+	case req.Invite != "":
+		invite, err := h.invites.ValidateToken(req.Invite)
+		if err != nil || invite.Room != info.Name || invite.Key != info.Key ||
+			invite.User != "" && invite.User != client.ID {
+			return "", errors.New("the invite is not valid for you or has expired")
+		}
+		return info.Key, nil
+	case info.Access == accessInvite:
+		return "", errors.New("the room is invite only")
+	case info.Access == accessPassword && req.Password == "":
+		return "", errors.New("the room needs a password")
+	case info.Access == accessPassword && !info.checkPassword(req.Password):
+		return "", errors.New("wrong password")
+	}
+	return info.Key, nil
+}
+
+// sendInvite answers the host of the client's room with an invite to it.
+func (c *client) sendInvite(req *InvitePayload) {
+	room := c.room
+	ctx, cancel := context.WithTimeout(c.ctx, c.cfg.WriteTimeout)
+	defer cancel()
+	info := c.hub.index.get(ctx, room.name)
+	if info == nil || info.Host != c.ID {
+		c.trySend(sendMessage(msgError, "Cannot invite: only the room's host can"))
+		return
+	}
+	token, err := c.hub.invites.GenerateToken(&invitePayload{Room: info.Name, Key: info.Key, User: req.User})
+	if err != nil {
+		slog.Error("sendInvite: failed to sign invite", "error", err, "room", room.name)
+		c.trySend(sendMessage(msgError, "Cannot invite. Please try again later."))
+		return
+	}
+	c.trySend(sendKeyVal(msgInvite, "roomName", info.Name, "user", req.User, "token", token))
+}
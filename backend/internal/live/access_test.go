@@ -0,0 +1,51 @@
+package live
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+)
+
+// dropInRoom joins user to host's room with password, drops the connection
+// and returns the query that resumes its session.
+func dropInRoom(t *testing.T, srv *httptest.Server, host *testConn, user, room, password string) string {
+	t.Helper()
+	c := connect(t, srv, user, "")
+	token := c.await(msgSession, nil).Payload["token"].(string)
+	c.joined(lobbyName)
+	c.send(msgJoinRoom, map[string]any{"roomName": room, "password": password})
+	seq := c.joined(room).Payload["seq"].(float64)
+	c.conn.CloseNow()
+	host.await(msgStatus, func(p map[string]any) bool { return p["message"] == user+" has left "+room })
+	return fmt.Sprintf("&resume=%s&seq=%d", token, int64(seq))
+}
+
+func TestResumeIntoPasswordRoom(t *testing.T) {
+	srv := testServer(t, newMemKV(), newMemBus())
+	host := connect(t, srv, "alice", "")
+	host.send(msgJoinRoom, map[string]any{"roomName": "r", "password": "secret"})
+	host.joined("r")
+
+	resume := dropInRoom(t, srv, host, "bob", "r", "secret")
+	bob := connect(t, srv, "bob", resume)
+	if msg := bob.await(msgJoinRoom, nil); msg.Payload["roomName"] != "r" {
+		t.Errorf("bob came back to %v, not r", msg.Payload["roomName"])
+	}
+}
+
+func TestResumeBanned(t *testing.T) {
+	srv := testServer(t, newMemKV(), newMemBus())
+	host := connect(t, srv, "alice", "")
+	host.send(msgJoinRoom, map[string]any{"roomName": "r"})
+	host.joined("r")
+
+	resume := dropInRoom(t, srv, host, "bob", "r", "")
+	host.send(msgBan, map[string]any{"user": "bob"})
+	host.await(msgStatus, func(p map[string]any) bool { return p["message"] == "bob was banned by alice" })
+
+	bob := connect(t, srv, "bob", resume)
+	bob.await(msgError, func(p map[string]any) bool { return p["message"] == "Cannot rejoin r: you are banned from the room" })
+	if msg := bob.await(msgJoinRoom, nil); msg.Payload["roomName"] != lobbyName {
+		t.Errorf("banned bob came back to %v", msg.Payload["roomName"])
+	}
+}
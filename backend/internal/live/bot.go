@@ -0,0 +1,75 @@
+package live
+
+import (
+	"log/slog"
+
+	"gonext/internal/bot"
+	"gonext/internal/game"
+)
+
+// botDriver plays a bot's seat in one game. It wakes up on every update of
+// that game and answers whatever is waiting on the bot, until the game is
+// over or the room lets go of it.
+type botDriver struct {
+	bot  *bot.Bot
+	game game.Game
+	kick chan struct{}
+	stop chan struct{}
+}
+
+func newBotDriver(b *bot.Bot) *botDriver {
+	return &botDriver{bot: b, kick: make(chan struct{}, 1), stop: make(chan struct{})}
+}
+
+func (d *botDriver) poke() {
+	select {
+	case d.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (d *botDriver) run() {
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-d.kick:
+			if !d.act() {
+				return
+			}
+		}
+	}
+}
+
+// act returns false once the game is over.
+func (d *botDriver) act() bool {
+	state := d.game.GetState()
+	switch state.Status {
+	case game.StatusFin:
+		return false
+	case game.StatusInProgress:
+	default:
+		return true
+	}
+
+	var err error
+	switch {
+	case state.Takeback != "" && state.Takeback != bot.Name:
+		err = d.game.AcceptTakeback(bot.Name)
+	case state.DrawOffer != "" && state.DrawOffer != bot.Name:
+		err = d.game.DeclineDraw(bot.Name)
+	case state.Players[state.Turn] == bot.Name:
+		mv, thinkErr := d.bot.Think(state)
+		if thinkErr != nil {
+			slog.Error("bot: failed to pick a move", "error", thinkErr, "gameID", state.ID)
+			return true
+		}
+		err = d.game.Move(bot.Name, mv)
+	}
+	if err != nil {
+		// The state moved on while the bot was thinking; the update that
+		// changed it has already kicked the bot again.
+		slog.Debug("bot: action failed", "error", err, "gameID", state.ID)
+	}
+	return true
+}
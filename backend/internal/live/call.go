@@ -0,0 +1,77 @@
+package live
+
+import (
+	"encoding/json"
+	"slices"
+
+	"gonext/internal/webrtc"
+)
+
+// handleSignal passes a video call signal on to the peers it is meant for,
+// on the instance running the room.
+func (r *room) handleSignal(msg *roomMsg) {
+	client := msg.Client
+	if r.forward(client, msg.Type, msg.Payload) {
+		return
+	}
+	var sig webrtc.Signal
+	if err := json.Unmarshal(msg.Payload, &sig); err != nil {
+		client.trySend(sendMessage(msgError, "Invalid payload format: "+err.Error()))
+		return
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	to, changed, err := r.call.Route(client.ID, &sig)
+	if err != nil {
+		client.trySend(sendMessage(msgError, "Cannot signal: "+err.Error()))
+		return
+	}
+	r.signalLocked(client.ID, &sig, to)
+	if changed {
+		r.sendRosterLocked(nil)
+	}
+}
+
+// signalLocked sends sig from sender to the clients of the users in to.
+func (r *room) signalLocked(sender string, sig *webrtc.Signal, to []string) {
+	msg := r.signalMsg(sender, sig)
+	for client := range r.clients {
+		if slices.Contains(to, client.ID) {
+			client.trySend(msg)
+		}
+	}
+}
+
+// sendRosterLocked tells who is in the call to client, or to everyone in the
+// room if client is nil.
+func (r *room) sendRosterLocked(client *client) {
+	msg := r.signalMsg("_server", &webrtc.Signal{Type: webrtc.Roster, Members: r.call.Members()})
+	if client != nil {
+		client.trySend(msg)
+		return
+	}
+	for client := range r.clients {
+		client.trySend(msg)
+	}
+}
+
+// dropFromCallLocked takes a user who left the room out of its call.
+func (r *room) dropFromCallLocked(id string) {
+	if to, ok := r.call.Drop(id); ok {
+		r.signalLocked(id, &webrtc.Signal{Type: webrtc.Leave}, to)
+		r.sendRosterLocked(nil)
+	}
+}
+
+func (r *room) signalMsg(sender string, sig *webrtc.Signal) []byte {
+	payload, err := json.Marshal(sig)
+	if err != nil {
+		return internalError(err)
+	}
+	msg, err := json.Marshal(&roomMsg{Type: msgVidSignal, Sender: sender, Payload: payload})
+	if err != nil {
+		return internalError(err)
+	}
+	return msg
+}
@@ -12,16 +12,29 @@ import (
 )
 
 type client struct {
-	cfg    *config.WS
-	ID     string
-	hub    *hub
-	conn   *websocket.Conn
-	send   chan []byte
-	recv   chan []byte
-	room   *room
-	user   *token.UserPayload
-	ctx    context.Context
-	cancel context.CancelFunc
+	cfg     *config.WS
+	ID      string
+	hub     *hub
+	conn    *websocket.Conn
+	send    chan []byte
+	recv    chan []byte
+	room    *room
+	user    *token.UserPayload
+	ratings map[string]ratingInfo // by game name; guarded by the room lock
+	node    string                // instance the client is connected to, if not this one
+	session string                // resume token
+	resume  *resumePoint          // where the client left off, when it comes back
+	roomKey string                // of the room it was let into; hub goroutine only
+	arrived time.Time             // in its room
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+type resumePoint struct {
+	room  string
+	key   string // of the room when the client was let in
+	owner string // of the room now
+	since int64  // last message seen there, or noResume
 }
 
 func newClient(h *hub, conn *websocket.Conn, user *token.UserPayload, cfg *config.WS) *client {
@@ -40,6 +53,16 @@ func newClient(h *hub, conn *websocket.Conn, user *token.UserPayload, cfg *confi
 	}
 }
 
+// newRemoteClient stands in for a client connected to another instance, in
+// a room this one owns. Its messages arrive on recv; what it is sent goes
+// back over the bus.
+func newRemoteClient(h *hub, node string, user *token.UserPayload, ratings map[string]ratingInfo) *client {
+	c := newClient(h, nil, user, h.cfg)
+	c.node = node
+	c.ratings = ratings
+	return c
+}
+
 func (c *client) start() {
 	go c.writePump()
 	go c.readPump()
@@ -155,8 +178,10 @@ func (c *client) processPump() {
 			msg.Sender = c.ID
 			msg.Client = c
 			switch msg.Type {
-			case msgChat, msgVidSignal, msgRawSignal:
+			case msgChat, msgRawSignal:
 				c.room.handleRelay(&msg)
+			case msgVidSignal:
+				c.room.handleSignal(&msg)
 			case msgGameState:
 				var payload GameMessagePayload
 				if err := json.Unmarshal(msg.Payload, &payload); err != nil {
@@ -168,16 +193,50 @@ func (c *client) processPump() {
 			case msgJoinRoom:
 				var payload JoinRoomPayload
 				if err := json.Unmarshal(msg.Payload, &payload); err == nil {
-					if roomID := payload.RoomName; roomID != "" {
-						c.hub.joinRoom <- &crPair{Client: c, RoomName: roomID}
-					} else {
+					if roomID := payload.RoomName; roomID == "" {
 						c.trySend(sendMessage(msgError, "invalid format: missing roomName"))
+					} else if err := payload.settings(); err != nil {
+						c.trySend(sendMessage(msgError, "Cannot open room: "+err.Error()))
+					} else {
+						c.hub.joinRoom <- &crPair{Client: c, RoomName: roomID, Settings: &payload, Owner: c.hub.findOwner(roomID)}
 					}
 				} else {
 					c.trySend(sendMessage(msgError, "invalid format for join room"))
 				}
+			case msgGetRooms:
+				var payload GetRoomsPayload
+				if len(msg.Payload) > 0 {
+					if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+						c.trySend(sendMessage(msgError, "Invalid payload format: "+err.Error()))
+						continue
+					}
+				}
+				c.sendRooms(&payload)
 			case msgLeaveRoom:
 				c.hub.leaveRoom <- c
+			case msgInvite:
+				var payload InvitePayload
+				if len(msg.Payload) > 0 {
+					if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+						c.trySend(sendMessage(msgError, "Invalid payload format: "+err.Error()))
+						continue
+					}
+				}
+				c.sendInvite(&payload)
+			case msgKick, msgBan, msgMute, msgTransferOwner:
+				var payload ModeratePayload
+				if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+					c.trySend(sendMessage(msgError, "Invalid payload format: "+err.Error()))
+					continue
+				}
+				c.room.handleModerate(c, msg.Type, &payload)
+			case msgQueue:
+				var payload QueuePayload
+				if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+					c.trySend(sendMessage(msgError, "Invalid payload format: "+err.Error()))
+					continue
+				}
+				c.handleQueue(&payload)
 			default:
 				slog.Warn("processPump: Unknown message type received", "type", msg.Type, "client", c.ID)
 				c.trySend(sendMessage(msgError, "Unknown message type: "+msg.Type))
@@ -187,6 +246,10 @@ func (c *client) processPump() {
 }
 
 func (c *client) trySend(msg []byte) {
+	if c.node != "" {
+		c.hub.cluster.send(c.node, &busMsg{Kind: busDeliver, Room: c.room.name, Client: c.ID, Msg: msg})
+		return
+	}
 	defer func() {
 		if r := recover(); r != nil {
 			slog.Warn("trySend: Attempted to send on closed channel", "client", c.ID, "recover", r)
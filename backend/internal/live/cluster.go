@@ -0,0 +1,124 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"gonext/internal/config"
+	"gonext/internal/repo"
+	"gonext/internal/token"
+
+	"github.com/google/uuid"
+)
+
+// What one instance tells another about a room.
+const (
+	busJoin     = "join"     // a client on the sender joined the room
+	busLeave    = "leave"    // and left it again
+	busForward  = "forward"  // a client's message, for the room's owner
+	busDeliver  = "deliver"  // a message from the room's owner, for a client
+	busAnnounce = "announce" // a message for everyone in the room, for its owner
+	busKick     = "kick"     // the room's host sent a client away
+)
+
+type busMsg struct {
+	Kind    string                `json:"kind"`
+	From    string                `json:"from"`
+	Room    string                `json:"room"`
+	Client  string                `json:"client"`
+	User    *token.UserPayload    `json:"user,omitempty"`    // on join
+	Ratings map[string]ratingInfo `json:"ratings,omitempty"` // on join
+	Since   int64                 `json:"since,omitempty"`   // on join; noResume for a fresh one
+	Msg     json.RawMessage       `json:"msg,omitempty"`
+
+	owner string // of the room, found on receiving a join
+}
+
+type outMsg struct {
+	node string
+	msg  *busMsg
+}
+
+// cluster links this instance to the others sharing the Redis. Each room is
+// owned by one instance, which runs its game; clients in the room on other
+// instances reach it over the owner's channel.
+type cluster struct {
+	node string
+	kv   repo.KVStore
+	bus  repo.Bus
+	cfg  *config.WS
+	out  chan outMsg
+}
+
+func newCluster(kv repo.KVStore, bus repo.Bus, cfg *config.WS) *cluster {
+	return &cluster{
+		node: uuid.NewString(),
+		kv:   kv,
+		bus:  bus,
+		cfg:  cfg,
+		out:  make(chan outMsg, cfg.MsgBuffer),
+	}
+}
+
+// listen returns the messages other instances send this one.
+func (c *cluster) listen(ctx context.Context) <-chan []byte {
+	return c.bus.Subscribe(ctx, fmt.Sprintf(c.cfg.NodeChannel, c.node))
+}
+
+// send queues msg for node without waiting on Redis.
+func (c *cluster) send(node string, msg *busMsg) {
+	msg.From = c.node
+	select {
+	case c.out <- outMsg{node: node, msg: msg}:
+	default:
+		slog.Warn("cluster: send queue full", "node", node, "kind", msg.Kind, "room", msg.Room)
+	}
+}
+
+func (c *cluster) run() {
+	for out := range c.out {
+		data, err := json.Marshal(out.msg)
+		if err != nil {
+			slog.Error("cluster: failed to encode message", "error", err, "room", out.msg.Room)
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), c.cfg.WriteTimeout)
+		if err := c.bus.Publish(ctx, fmt.Sprintf(c.cfg.NodeChannel, out.node), data); err != nil {
+			slog.Error("cluster: failed to publish", "error", err, "node", out.node, "room", out.msg.Room)
+		}
+		cancel()
+	}
+}
+
+// claim makes this instance the room's owner unless another one already is,
+// and returns the owner. An owner renews its claim by claiming again.
+func (c *cluster) claim(ctx context.Context, room string) (string, error) {
+	owner, err := c.kv.Claim(ctx, fmt.Sprintf(c.cfg.OwnerKey, room), c.node, c.cfg.ClusterTTL)
+	if err != nil {
+		return "", fmt.Errorf("cluster: failed to claim room: %w", err)
+	}
+	return owner, nil
+}
+
+// release gives up the room if this instance owns it.
+func (c *cluster) release(ctx context.Context, room string) error {
+	if err := c.kv.DelIf(ctx, fmt.Sprintf(c.cfg.OwnerKey, room), c.node); err != nil {
+		return fmt.Errorf("cluster: failed to release room: %w", err)
+	}
+	return nil
+}
+
+func (c *cluster) heartbeat(ctx context.Context) error {
+	if err := c.kv.Set(ctx, fmt.Sprintf(c.cfg.NodeKey, c.node), "1", c.cfg.ClusterTTL); err != nil {
+		return fmt.Errorf("cluster: failed to send heartbeat: %w", err)
+	}
+	return nil
+}
+
+// alive reports whether node has sent a heartbeat within ClusterTTL.
+func (c *cluster) alive(ctx context.Context, node string) bool {
+	_, err := c.kv.Get(ctx, fmt.Sprintf(c.cfg.NodeKey, node))
+	return err == nil
+}
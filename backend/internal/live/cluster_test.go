@@ -0,0 +1,21 @@
+package live
+
+import "testing"
+
+func TestJoinRoomOnAnotherInstance(t *testing.T) {
+	kv, bus := newMemKV(), newMemBus()
+	first, second := testServer(t, kv, bus), testServer(t, kv, bus)
+
+	alice := connect(t, first, "alice", "")
+	alice.send(msgJoinRoom, map[string]any{"roomName": "r"})
+	alice.joined("r")
+
+	bob := connect(t, second, "bob", "")
+	bob.joined(lobbyName)
+	bob.send(msgJoinRoom, map[string]any{"roomName": "r"})
+	bob.joined("r")
+	alice.await(msgStatus, func(p map[string]any) bool { return p["message"] == "bob has joined r" })
+
+	alice.send(msgChat, map[string]any{"message": "hi"})
+	bob.await(msgChat, nil)
+}
@@ -1,57 +1,129 @@
 package live
 
 import (
+	"context"
+	"encoding/json"
 	"gonext/internal/config"
 	"gonext/internal/game"
 	"log/slog"
 	"time"
 )
 
+const lobbyName = "Lobby"
+
 type hub struct {
 	registry *game.Registry
+	rec      *recorder
+	live     *liveGames
+	cluster  *cluster
+	sessions *sessions
+	index    *roomIndex
+	invites  inviteManager
+	bans     *bans
 	cfg      *config.WS
 	rooms    map[string]*room
-	clients  map[*client]struct{}
+	users    map[string]map[*client]struct{} // connections here, by user
+	proxies  map[proxyKey]*client            // clients on other instances, in rooms owned here
+	lobby    *room
+	queue    []*queueEntry // players queued here; instances do not share a queue
+
+	surveying bool // a tick's survey has yet to report back
 
 	register   chan *client
 	unregister chan *client
 	joinRoom   chan *crPair
 	leaveRoom  chan *client
+	enqueue    chan *queueEntry
+	dequeue    chan *client
+	kick       chan *kickOrder
+	ended      chan *room
+	bus        chan *busMsg
+	surveyed   chan *survey
+	moved      chan *ownerChange
+}
+
+type proxyKey struct {
+	node, room, client string
 }
 
-func newhub(registry *game.Registry, cfg *config.WS) *hub {
-	return &hub{
+func newhub(registry *game.Registry, rec *recorder, cl *cluster, sess *sessions, index *roomIndex, invites inviteManager, bans *bans, cfg *config.WS) *hub {
+	h := &hub{
 		registry:   registry,
+		rec:        rec,
+		live:       newLiveGames(),
+		cluster:    cl,
+		sessions:   sess,
+		index:      index,
+		invites:    invites,
+		bans:       bans,
 		cfg:        cfg,
 		rooms:      make(map[string]*room),
-		clients:    make(map[*client]struct{}),
+		users:      make(map[string]map[*client]struct{}),
+		proxies:    make(map[proxyKey]*client),
 		register:   make(chan *client, cfg.RegisterBuffer),
 		unregister: make(chan *client, cfg.RegisterBuffer),
 		joinRoom:   make(chan *crPair, cfg.RoomBuffer),
 		leaveRoom:  make(chan *client, cfg.RoomBuffer),
+		enqueue:    make(chan *queueEntry, cfg.RoomBuffer),
+		dequeue:    make(chan *client, cfg.RoomBuffer),
+		kick:       make(chan *kickOrder, cfg.RoomBuffer),
+		ended:      make(chan *room, cfg.RoomBuffer),
+		bus:        make(chan *busMsg, cfg.MsgBuffer),
+		surveyed:   make(chan *survey, 1),
+		moved:      make(chan *ownerChange, cfg.RoomBuffer),
 	}
+	index.announce = func(msg []byte) { h.lobby.announce(msg) }
+	return h
 }
 
 func (h *hub) run() {
-	lobby := newRoom("Lobby", h.registry)
+	lobby := h.openRoom(lobbyName, h.findOwner(lobbyName))
 	h.rooms[lobby.name] = lobby
+	h.lobby = lobby
+	h.restoreRooms()
+	h.tick()
+
+	go h.listen(h.cluster.listen(context.Background()))
+	ticker := time.NewTicker(h.cfg.ClusterTTL / 3)
+	defer ticker.Stop()
 
 	for {
 		select {
+		case msg := <-h.bus:
+			h.handleBus(msg)
+
+		case <-ticker.C:
+			h.tick()
+
+		case survey := <-h.surveyed:
+			h.surveying = false
+			if survey != nil {
+				h.applySurvey(survey)
+			}
+
+		case change := <-h.moved:
+			h.moveRoom(change)
+
 		case client := <-h.register:
-			h.clients[client] = struct{}{}
-			lobby.addClient(client)
+			h.addConnection(client)
+			client.trySend(sendKeyVal(msgSession, "token", client.session, "resumed", client.resume != nil))
+			if resume := client.resume; resume != nil {
+				h.reenter(client, resume)
+			} else {
+				h.enter(client, lobby, noResume, "")
+			}
 			client.start()
 			slog.Debug("Registered: ", "client", client.ID)
 
 		case client := <-h.unregister:
+			h.removeFromQueue(client)
 			client.room.removeClient(client)
-			if client.room != lobby && len(client.room.clients) == 0 {
-				delete(h.rooms, client.room.name)
-			}
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				slog.Debug("Unregistered: ", "client", client.ID)
+			h.closeIfEmpty(client.room)
+			if h.removeConnection(client) {
+				if !h.sessionInUse(client) {
+					h.sessions.close(client.session, client.ID, client.room.name, client.roomKey)
+				}
+				slog.Debug("Unregistered: ", "client", client.ID, "connections", len(h.users[client.ID]))
 			}
 			time.AfterFunc(100*time.Millisecond, client.stop)
 
@@ -62,17 +134,21 @@ func (h *hub) run() {
 			if client.room.name == roomName {
 				continue
 			}
-			oldRoom := client.room
-			oldRoom.removeClient(client)
-			if oldRoom != lobby && len(oldRoom.clients) == 0 {
-				delete(h.rooms, oldRoom.name)
-			}
 			room, ok := h.rooms[roomName]
 			if !ok {
-				room = newRoom(roomName, h.registry)
+				room = h.openRoom(roomName, pair.Owner)
 				h.rooms[room.name] = room
+				if room.owner == "" {
+					h.index.update(room.name, func(info *roomInfo) { pair.Settings.apply(info, client.ID) })
+				}
+			}
+			key, err := h.admit(room, client, pair.Settings)
+			if err != nil {
+				client.trySend(sendMessage(msgError, "Cannot join "+roomName+": "+err.Error()))
+				h.closeIfEmpty(room)
+				continue
 			}
-			room.addClient(client)
+			h.moveClient(client, room, key)
 			slog.Debug("Client joined room successfully.", "client", client.ID, "roomID", roomName)
 
 		case client := <-h.leaveRoom:
@@ -81,11 +157,388 @@ func (h *hub) run() {
 				continue
 			}
 			room.removeClient(client)
-			if len(room.clients) == 0 {
-				delete(h.rooms, room.name)
-			}
+			h.closeIfEmpty(room)
 			slog.Debug("Client left room.", "client", client.ID, "roomID", room.name)
-			lobby.addClient(client)
+			h.enter(client, lobby, noResume, "")
+
+		case entry := <-h.enqueue:
+			h.addToQueue(entry)
+
+		case order := <-h.kick:
+			h.evict(order)
+
+		case room := <-h.ended:
+			// Restored rooms no one came back to close once their game is over.
+			if h.rooms[room.name] == room && room != lobby && room.owner == "" && len(room.clients) == 0 {
+				room.close()
+				h.closeIfEmpty(room)
+			}
+
+		case client := <-h.dequeue:
+			if entry := h.removeUserFromQueue(client.ID); entry != nil {
+				entry.client.trySend(sendKeyVal(msgQueue, "status", "cancelled"))
+				if entry.client != client {
+					client.trySend(sendKeyVal(msgQueue, "status", "cancelled"))
+				}
+			}
+		}
+	}
+}
+
+// restoreRooms brings back the rooms whose games were running when the
+// server stopped. Their players get the usual DisconnectTimeout to return.
+func (h *hub) restoreRooms() {
+	ctx, cancel := context.WithTimeout(context.Background(), saveTimeout)
+	defer cancel()
+
+	for _, snap := range h.rec.snaps.load(ctx) {
+		room, ok := h.rooms[snap.Room]
+		if !ok {
+			room = h.openRoom(snap.Room, h.findOwner(snap.Room))
+		}
+		if room.owner != "" {
+			continue // still running on another instance
+		}
+		if snap.Info != nil {
+			h.index.adopt(snap.Info)
+		}
+		if err := room.restore(snap); err != nil {
+			slog.Error("hub: failed to restore room", "error", err, "room", snap.Room)
+			h.rec.snaps.remove(snap.Room)
+			continue
+		}
+		h.rooms[room.name] = room
+		slog.Info("Restored room.", "roomID", room.name, "gameID", snap.Game.ID)
+	}
+}
+
+func (h *hub) moveClient(client *client, room *room, key string) {
+	oldRoom := client.room
+	oldRoom.removeClient(client)
+	h.closeIfEmpty(oldRoom)
+	h.enter(client, room, noResume, key)
+}
+
+// enter puts a client connected here in room, which let it in under key, and
+// remembers it for when the client comes back.
+func (h *hub) enter(client *client, room *room, since int64, key string) {
+	client.roomKey = key
+	room.addClient(client, since)
+	h.sessions.move(client.session, client.ID, room.name, key)
+}
+
+// reenter brings a client that came back to the room it left, unless the
+// room no longer lets it in, in which case it goes to the lobby.
+func (h *hub) reenter(client *client, resume *resumePoint) {
+	room := h.roomNamed(resume.room, resume.owner)
+	key, err := h.admit(room, client, &JoinRoomPayload{key: resume.key})
+	if err != nil {
+		client.trySend(sendMessage(msgError, "Cannot rejoin "+room.name+": "+err.Error()))
+		h.closeIfEmpty(room)
+		h.enter(client, h.lobby, noResume, "")
+		return
+	}
+	h.enter(client, room, resume.since, key)
+}
+
+func (h *hub) addConnection(c *client) {
+	if h.users[c.ID] == nil {
+		h.users[c.ID] = make(map[*client]struct{})
+	}
+	h.users[c.ID][c] = struct{}{}
+}
+
+func (h *hub) removeConnection(c *client) bool {
+	if _, ok := h.users[c.ID][c]; !ok {
+		return false
+	}
+	delete(h.users[c.ID], c)
+	if len(h.users[c.ID]) == 0 {
+		delete(h.users, c.ID)
+	}
+	return true
+}
+
+// sessionInUse reports whether another connection of the client's user, one
+// that came back with its resume token, still holds its session.
+func (h *hub) sessionInUse(client *client) bool {
+	for other := range h.users[client.ID] {
+		if other.session == client.session {
+			return true
+		}
+	}
+	return false
+}
+
+// roomNamed finds the room, opening it with owner if no one is in it yet.
+func (h *hub) roomNamed(name, owner string) *room {
+	room, ok := h.rooms[name]
+	if !ok {
+		room = h.openRoom(name, owner)
+		h.rooms[room.name] = room
+	}
+	return room
+}
+
+// findOwner claims the room and returns its owner, "" for this instance.
+// As it waits on Redis, whatever hands the hub a room to open calls it
+// first, off the hub goroutine.
+func (h *hub) findOwner(name string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), h.cfg.WriteTimeout)
+	defer cancel()
+	owner, err := h.cluster.claim(ctx, name)
+	if err != nil {
+		slog.Error("hub: failed to find room owner, running it here", "error", err, "room", name)
+		return ""
+	}
+	if owner == h.cluster.node {
+		return ""
+	}
+	return owner
+}
+
+// openRoom makes a room run by owner, as found by findOwner. The caller
+// adds it to the hub.
+func (h *hub) openRoom(name, owner string) *room {
+	room := newRoom(name, h)
+	room.owner = owner
+	return room
+}
+
+// closeIfEmpty drops a room no one is in any more, other than the lobby.
+func (h *hub) closeIfEmpty(room *room) {
+	if room == h.lobby || len(room.clients) > 0 {
+		return
+	}
+	delete(h.rooms, room.name)
+	if room.owner == "" {
+		h.index.remove(room.name)
+		go h.release(room.name)
+	}
+}
+
+func (h *hub) release(name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.cfg.WriteTimeout)
+	defer cancel()
+	if err := h.cluster.release(ctx, name); err != nil {
+		slog.Error("hub: failed to release room", "error", err, "room", name)
+	}
+}
+
+// listen passes on what other instances send this one. It finds the owner
+// of the room a client joins before the hub gets to the join.
+func (h *hub) listen(bus <-chan []byte) {
+	for data := range bus {
+		msg := &busMsg{}
+		if err := json.Unmarshal(data, msg); err != nil {
+			slog.Error("hub: invalid bus message", "error", err)
+			continue
+		}
+		if msg.Kind == busJoin {
+			msg.owner = h.findOwner(msg.Room)
+		}
+		h.bus <- msg
+	}
+}
+
+// handleBus acts on a message from another instance.
+func (h *hub) handleBus(msg *busMsg) {
+	key := proxyKey{node: msg.From, room: msg.Room, client: msg.Client}
+	switch msg.Kind {
+	case busJoin:
+		if msg.User == nil || msg.User.Username != msg.Client {
+			slog.Error("hub: join without matching user", "node", msg.From, "room", msg.Room)
+			return
+		}
+		if proxy, ok := h.proxies[key]; ok {
+			if msg.Since != noResume {
+				proxy.room.resume(proxy, msg.Since)
+			}
+			return
+		}
+		room, ok := h.rooms[msg.Room]
+		if !ok {
+			room = h.openRoom(msg.Room, msg.owner)
+		} else if room.owner != "" && msg.owner == "" {
+			// It was handed over here since the last tick; the join
+			// follows once the room has moved.
+			name, from := room.name, room.owner
+			go func() {
+				if change := h.checkOwner(name, from); change != nil {
+					change.join = msg
+					h.moved <- change
+				}
+			}()
+			return
+		}
+		if room.owner != "" {
+			// The sender will find the real owner on its next tick.
+			slog.Warn("hub: join for a room run elsewhere", "node", msg.From, "room", msg.Room)
+			return
+		}
+		h.rooms[room.name] = room
+		proxy := newRemoteClient(h, msg.From, msg.User, msg.Ratings)
+		h.proxies[key] = proxy
+		room.addClient(proxy, msg.Since)
+		go proxy.processPump()
+
+	case busLeave:
+		h.dropProxy(key)
+
+	case busForward:
+		proxy, ok := h.proxies[key]
+		if !ok {
+			return
+		}
+		select {
+		case proxy.recv <- msg.Msg:
+		default:
+			slog.Error("hub: remote client recv queue full", "client", proxy.ID, "node", proxy.node)
+			proxy.trySend(sendMessage(msgError, "Server busy. Please try again later."))
+		}
+
+	case busDeliver:
+		if room, ok := h.rooms[msg.Room]; ok {
+			room.deliver(msg.Client, msg.Msg)
+		}
+
+	case busKick:
+		h.evict(&kickOrder{room: msg.Room, user: msg.Client, msg: msg.Msg})
+
+	case busAnnounce:
+		// Not passed on again, should the room have moved meanwhile.
+		if room, ok := h.rooms[msg.Room]; ok && room.owner == "" {
+			room.announce(msg.Msg)
+		}
+	}
+}
+
+func (h *hub) dropProxy(key proxyKey) {
+	proxy, ok := h.proxies[key]
+	if !ok {
+		return
+	}
+	delete(h.proxies, key)
+	proxy.cancel()
+	proxy.room.removeClient(proxy)
+	h.closeIfEmpty(proxy.room)
+}
+
+// ownerChange is a room's new owner, found off the hub goroutine.
+type ownerChange struct {
+	room  string
+	from  string        // owner when the check started
+	owner string        // "" for this instance
+	snap  *roomSnapshot // of a room taken over
+	info  *roomInfo     // likewise
+	join  *busMsg       // to handle once the room is taken over
+}
+
+// survey is what a tick found out about the cluster.
+type survey struct {
+	changes []*ownerChange
+	alive   map[string]bool // by instance the proxies came from
+}
+
+// checkOwner renews or takes over the room, or follows it to its new owner,
+// and returns the change, if any. It waits on Redis, so the hub goroutine
+// must not call it.
+func (h *hub) checkOwner(name, from string) *ownerChange {
+	ctx, cancel := context.WithTimeout(context.Background(), h.cfg.WriteTimeout)
+	defer cancel()
+
+	owner, err := h.cluster.claim(ctx, name)
+	if err != nil {
+		slog.Error("hub: failed to check room owner", "error", err, "room", name)
+		return nil
+	}
+	if owner == h.cluster.node {
+		owner = ""
+	}
+	if owner == from {
+		return nil
+	}
+	change := &ownerChange{room: name, from: from, owner: owner}
+	if owner == "" {
+		change.snap = h.rec.snaps.get(ctx, name)
+		if change.info = h.index.get(ctx, name); change.info == nil && change.snap != nil {
+			change.info = change.snap.Info
+		}
+	}
+	return change
+}
+
+// moveRoom hands the room to its new owner, unless it moved or closed here
+// since the check.
+func (h *hub) moveRoom(change *ownerChange) {
+	room, ok := h.rooms[change.room]
+	if !ok || room.owner != change.from {
+		return
+	}
+	if change.owner == "" {
+		if change.info != nil {
+			h.index.adopt(change.info)
+		}
+		slog.Info("hub: took over room", "roomID", room.name)
+	}
+	room.setOwner(change.owner, change.snap)
+	if change.join != nil {
+		h.handleBus(change.join)
+	}
+}
+
+// tick starts a survey of the cluster, unless the last one has yet to
+// report back.
+func (h *hub) tick() {
+	if h.surveying {
+		return
+	}
+	h.surveying = true
+	owners := make(map[string]string, len(h.rooms))
+	for name, room := range h.rooms {
+		owners[name] = room.owner
+	}
+	nodes := make(map[string]struct{})
+	for key := range h.proxies {
+		nodes[key.node] = struct{}{}
+	}
+	go func() { h.surveyed <- h.survey(owners, nodes) }()
+}
+
+// survey keeps this instance's claims alive, checks who owns its rooms now
+// and which of the instances its proxies came from still answer. It
+// returns nil if the cluster is unreachable.
+func (h *hub) survey(owners map[string]string, nodes map[string]struct{}) *survey {
+	ctx, cancel := context.WithTimeout(context.Background(), h.cfg.ClusterTTL/3)
+	defer cancel()
+
+	if err := h.cluster.heartbeat(ctx); err != nil {
+		slog.Error("hub: cluster unreachable", "error", err)
+		return nil
+	}
+	s := &survey{alive: make(map[string]bool, len(nodes))}
+	for name, from := range owners {
+		if change := h.checkOwner(name, from); change != nil {
+			s.changes = append(s.changes, change)
+		}
+	}
+	for node := range nodes {
+		s.alive[node] = h.cluster.alive(ctx, node)
+	}
+	return s
+}
+
+// applySurvey moves rooms whose owner went away, taking over the ones it
+// can, and drops clients of instances that stopped answering.
+func (h *hub) applySurvey(s *survey) {
+	for _, change := range s.changes {
+		h.moveRoom(change)
+	}
+	h.index.refresh()
+
+	for key, proxy := range h.proxies {
+		if alive, ok := s.alive[key.node]; ok && !alive || proxy.room.owner != "" {
+			h.dropProxy(key)
 		}
 	}
 }
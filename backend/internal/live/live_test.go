@@ -0,0 +1,259 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gonext/internal/config"
+	"gonext/internal/game"
+	"gonext/internal/mdw"
+	"gonext/internal/model"
+	"gonext/internal/token"
+
+	"github.com/coder/websocket"
+)
+
+// memKV is a KVStore in memory. Nothing expires.
+type memKV struct {
+	mu    sync.Mutex
+	vals  map[string]string
+	lists map[string]map[string]bool
+}
+
+func newMemKV() *memKV {
+	return &memKV{vals: make(map[string]string), lists: make(map[string]map[string]bool)}
+}
+
+func (m *memKV) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.vals[key] = value
+	return nil
+}
+
+func (m *memKV) Del(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.vals, key)
+	return nil
+}
+
+func (m *memKV) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.vals[key]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return value, nil
+}
+
+func (m *memKV) Claim(ctx context.Context, key, value string, ttl time.Duration) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if held, ok := m.vals[key]; ok {
+		return held, nil
+	}
+	m.vals[key] = value
+	return value, nil
+}
+
+func (m *memKV) DelIf(ctx context.Context, key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.vals[key] == value {
+		delete(m.vals, key)
+	}
+	return nil
+}
+
+func (m *memKV) ListAdd(ctx context.Context, key, val string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lists[key] == nil {
+		m.lists[key] = make(map[string]bool)
+	}
+	m.lists[key][val] = true
+	return nil
+}
+
+func (m *memKV) ListDel(ctx context.Context, key, val string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.lists[key], val)
+	return nil
+}
+
+func (m *memKV) ListCheck(ctx context.Context, key, val string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lists[key][val], nil
+}
+
+func (m *memKV) ListGet(ctx context.Context, key string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	vals := make([]string, 0, len(m.lists[key]))
+	for val := range m.lists[key] {
+		vals = append(vals, val)
+	}
+	return vals, nil
+}
+
+func (m *memKV) ListTrim(ctx context.Context, key string, age time.Duration) error {
+	return nil
+}
+
+type memBus struct {
+	mu   sync.Mutex
+	subs map[string]chan []byte
+}
+
+func newMemBus() *memBus {
+	return &memBus{subs: make(map[string]chan []byte)}
+}
+
+func (b *memBus) Publish(ctx context.Context, channel string, msg []byte) error {
+	b.mu.Lock()
+	sub := b.subs[channel]
+	b.mu.Unlock()
+	if sub != nil {
+		sub <- msg
+	}
+	return nil
+}
+
+func (b *memBus) Subscribe(ctx context.Context, channel string) <-chan []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sub := make(chan []byte, 256)
+	b.subs[channel] = sub
+	return sub
+}
+
+type noGames struct{}
+
+func (noGames) CreateGame(ctx context.Context, g *model.GameRecord) error { return nil }
+
+func (noGames) ReadGame(ctx context.Context, id string) (*model.GameRecord, error) {
+	return nil, errors.New("not found")
+}
+
+func (noGames) ListGamesByPlayer(ctx context.Context, user string, limit, offset int) ([]*model.GameRecord, error) {
+	return nil, nil
+}
+
+type noRatings struct{}
+
+func (noRatings) ReadRatings(ctx context.Context, user string) ([]*model.Rating, error) {
+	return nil, nil
+}
+
+func (noRatings) UpdateRatings(ctx context.Context, gameName string, users []string, update func([]*model.Rating)) ([]*model.Rating, error) {
+	return nil, errors.New("not stored")
+}
+
+func testConfig(t *testing.T) *config.WS {
+	t.Helper()
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.WS.InviteSecret = "test"
+	cfg.WS.ClusterTTL = 300 * time.Millisecond
+	return cfg.WS
+}
+
+// testServer serves the live router over kv and bus, taking the user's name
+// from the "u" query parameter.
+func testServer(t *testing.T, kv *memKV, bus *memBus) *httptest.Server {
+	t.Helper()
+	registry := game.NewRegistry()
+	registry.RegisterAll()
+	router := Router(registry, noGames{}, noRatings{}, kv, bus, testConfig(t))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("u")
+		user := &token.UserPayload{Username: name, Displayname: name, AccountType: model.AccountTypeGuest}
+		router.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), mdw.ContextKey("user"), user)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+type testMsg struct {
+	Type    string         `json:"type"`
+	Payload map[string]any `json:"payload"`
+}
+
+type testConn struct {
+	t    *testing.T
+	conn *websocket.Conn
+	msgs chan *testMsg
+}
+
+// connect opens a connection for user; query is added to the URL as is.
+func connect(t *testing.T, srv *httptest.Server, user, query string) *testConn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/?u=" + user + query
+	conn, _, err := websocket.Dial(context.Background(), url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &testConn{t: t, conn: conn, msgs: make(chan *testMsg, 1024)}
+	go func() {
+		for {
+			_, data, err := conn.Read(context.Background())
+			if err != nil {
+				return
+			}
+			msg := &testMsg{}
+			if json.Unmarshal(data, msg) == nil {
+				c.msgs <- msg
+			}
+		}
+	}()
+	t.Cleanup(func() { conn.CloseNow() })
+	return c
+}
+
+func (c *testConn) send(msgType string, payload any) {
+	c.t.Helper()
+	data, err := json.Marshal(map[string]any{"type": msgType, "payload": payload})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if err := c.conn.Write(context.Background(), websocket.MessageText, data); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// await returns the first message of msgType that ok accepts, skipping the
+// rest.
+func (c *testConn) await(msgType string, ok func(payload map[string]any) bool) *testMsg {
+	c.t.Helper()
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case msg := <-c.msgs:
+			if msg.Type == msgType && (ok == nil || ok(msg.Payload)) {
+				return msg
+			}
+		case <-timeout:
+			c.t.Fatalf("no %s message", msgType)
+			return nil
+		}
+	}
+}
+
+// joined waits for the client to enter room.
+func (c *testConn) joined(room string) *testMsg {
+	c.t.Helper()
+	return c.await(msgJoinRoom, func(p map[string]any) bool { return p["roomName"] == room })
+}
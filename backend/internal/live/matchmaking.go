@@ -0,0 +1,172 @@
+package live
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+
+	"gonext/internal/game"
+	"gonext/internal/rating"
+
+	"github.com/google/uuid"
+)
+
+type QueuePayload struct {
+	Action      string            `json:"action"` // "join" or "cancel"
+	GameName    string            `json:"gameName,omitempty"`
+	TimeControl *game.TimeControl `json:"timeControl,omitempty"`
+	MinRating   int               `json:"minRating,omitempty"` // 0 for no bound
+	MaxRating   int               `json:"maxRating,omitempty"` // 0 for no bound
+}
+
+type queueEntry struct {
+	client    *client
+	gameName  string
+	opts      *game.Options
+	rating    int
+	minRating int
+	maxRating int
+}
+
+func (e *queueEntry) accepts(rating int) bool {
+	return (e.minRating == 0 || rating >= e.minRating) &&
+		(e.maxRating == 0 || rating <= e.maxRating)
+}
+
+func (e *queueEntry) matches(other *queueEntry) bool {
+	if e.client.ID == other.client.ID || e.gameName != other.gameName {
+		return false
+	}
+	tc, otherTC := e.opts.TimeControl, other.opts.TimeControl
+	if (tc == nil) != (otherTC == nil) || tc != nil && *tc != *otherTC {
+		return false
+	}
+	return e.accepts(other.rating) && other.accepts(e.rating)
+}
+
+func (c *client) handleQueue(payload *QueuePayload) {
+	switch payload.Action {
+	case "join":
+		opts := &game.Options{TimeControl: payload.TimeControl}
+		seats, err := c.hub.registry.Seats(payload.GameName, opts)
+		if err != nil {
+			c.trySend(sendMessage(msgError, "Cannot queue: "+err.Error()))
+			return
+		}
+		if seats != 2 {
+			c.trySend(sendMessage(msgError, "Cannot queue: matchmaking is for two-player games"))
+			return
+		}
+		if payload.MinRating < 0 || payload.MaxRating < 0 ||
+			payload.MaxRating != 0 && payload.MinRating > payload.MaxRating {
+			c.trySend(sendMessage(msgError, "invalid rating range"))
+			return
+		}
+		c.hub.enqueue <- &queueEntry{
+			client:    c,
+			gameName:  payload.GameName,
+			opts:      opts,
+			minRating: payload.MinRating,
+			maxRating: payload.MaxRating,
+		}
+	case "cancel":
+		c.hub.dequeue <- c
+	default:
+		c.trySend(sendMessage(msgError, "unknown queue action: "+payload.Action))
+	}
+}
+
+// ratingFor is the client's rating for gameName, or the starting rating for
+// guests and newcomers. Only the hub goroutine may call it, as it reads
+// c.room.
+func (c *client) ratingFor(gameName string) int {
+	c.room.mu.RLock()
+	defer c.room.mu.RUnlock()
+	if info, ok := c.ratings[gameName]; ok {
+		return info.Rating
+	}
+	return rating.Initial
+}
+
+// addToQueue pairs entry with the longest waiting compatible player, or
+// queues it until one turns up. Only players connected to this instance are
+// paired; the queue is not shared across the cluster. Only the hub goroutine
+// may call it.
+func (h *hub) addToQueue(entry *queueEntry) {
+	entry.rating = entry.client.ratingFor(entry.gameName)
+	// A user queues once, from whichever of their connections asked last.
+	if old := h.removeUserFromQueue(entry.client.ID); old != nil && old.client != entry.client {
+		old.client.trySend(sendKeyVal(msgQueue, "status", "cancelled"))
+	}
+
+	idx := slices.IndexFunc(h.queue, entry.matches)
+	if idx == -1 {
+		h.queue = append(h.queue, entry)
+		entry.client.trySend(sendKeyVal(msgQueue, "status", "queued", "gameName", entry.gameName))
+		return
+	}
+	opponent := h.queue[idx]
+	h.queue = slices.Delete(h.queue, idx, idx+1)
+	h.startMatch(opponent, entry)
+}
+
+func (h *hub) removeUserFromQueue(user string) *queueEntry {
+	idx := slices.IndexFunc(h.queue, func(e *queueEntry) bool { return e.client.ID == user })
+	if idx == -1 {
+		return nil
+	}
+	entry := h.queue[idx]
+	h.queue = slices.Delete(h.queue, idx, idx+1)
+	return entry
+}
+
+func (h *hub) removeFromQueue(client *client) bool {
+	idx := slices.IndexFunc(h.queue, func(e *queueEntry) bool { return e.client == client })
+	if idx == -1 {
+		return false
+	}
+	h.queue = slices.Delete(h.queue, idx, idx+1)
+	return true
+}
+
+func (h *hub) startMatch(a, b *queueEntry) {
+	// No one else has a room of a fresh name, so the claim can follow.
+	room := h.openRoom("match-"+uuid.NewString()[:8], "")
+	h.rooms[room.name] = room
+	go h.findOwner(room.name)
+	h.index.update(room.name, func(info *roomInfo) {
+		info.Title = a.gameName + " match"
+		info.Visibility = roomUnlisted
+	})
+	for _, e := range []*queueEntry{a, b} {
+		e.client.trySend(sendKeyVal(msgQueue, "status", "matched", "roomName", room.name))
+		h.moveClient(e.client, room, "")
+	}
+
+	players := []string{a.client.ID, b.client.ID}
+	rand.Shuffle(len(players), func(i, j int) { players[i], players[j] = players[j], players[i] })
+	if err := room.startGame(a.gameName, a.opts, players); err != nil {
+		slog.Error("startMatch: failed to start game", "error", err, "room", room.name)
+		room.mu.RLock()
+		room.broadcastLocked(sendMessage(msgError, "Cannot create game: "+err.Error()))
+		room.mu.RUnlock()
+	}
+}
+
+// startGame creates a game with players already seated in the given order.
+func (r *room) startGame(gameName string, opts *game.Options, players []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.newGameLocked(gameName, opts); err != nil {
+		return err
+	}
+	r.series = newSeries()
+	for _, p := range players {
+		if err := r.game.Join(p); err != nil {
+			return err
+		}
+	}
+	r.game.Start()
+	return nil
+}
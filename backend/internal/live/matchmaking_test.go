@@ -0,0 +1,82 @@
+package live
+
+import (
+	"testing"
+
+	"gonext/internal/game"
+)
+
+func entry(user, gameName string, tc *game.TimeControl, rating, minRating, maxRating int) *queueEntry {
+	return &queueEntry{
+		client:    &client{ID: user},
+		gameName:  gameName,
+		opts:      &game.Options{TimeControl: tc},
+		rating:    rating,
+		minRating: minRating,
+		maxRating: maxRating,
+	}
+}
+
+func TestQueueMatches(t *testing.T) {
+	blitz := &game.TimeControl{Initial: 180, Increment: 2}
+	alice := entry("alice", "chess", blitz, 1500, 0, 0)
+	for _, tc := range []struct {
+		name  string
+		other *queueEntry
+		want  bool
+	}{
+		{"same game and clock", entry("bob", "chess", &game.TimeControl{Initial: 180, Increment: 2}, 1500, 0, 0), true},
+		{"same user", entry("alice", "chess", blitz, 1500, 0, 0), false},
+		{"other game", entry("bob", "go", blitz, 1500, 0, 0), false},
+		{"other clock", entry("bob", "chess", &game.TimeControl{Initial: 300}, 1500, 0, 0), false},
+		{"no clock", entry("bob", "chess", nil, 1500, 0, 0), false},
+		{"in bob's range", entry("bob", "chess", blitz, 1900, 1400, 1600), true},
+		{"above bob's range", entry("bob", "chess", blitz, 1900, 1600, 0), false},
+		{"below bob's range", entry("bob", "chess", blitz, 1300, 0, 1400), false},
+	} {
+		if got := alice.matches(tc.other); got != tc.want {
+			t.Errorf("%s: matches = %v, want %v", tc.name, got, tc.want)
+		}
+		if got := tc.other.matches(alice); got != tc.want {
+			t.Errorf("%s: matches the other way = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestQueuePairs(t *testing.T) {
+	srv := testServer(t, newMemKV(), newMemBus())
+	alice, bob := connect(t, srv, "alice", ""), connect(t, srv, "bob", "")
+	alice.joined(lobbyName)
+	bob.joined(lobbyName)
+
+	queue := map[string]any{"action": "join", "gameName": "tictactoe"}
+	alice.send(msgQueue, queue)
+	alice.await(msgQueue, func(p map[string]any) bool { return p["status"] == "queued" })
+	bob.send(msgQueue, queue)
+
+	matched := func(p map[string]any) bool { return p["status"] == "matched" }
+	room := alice.await(msgQueue, matched).Payload["roomName"]
+	if other := bob.await(msgQueue, matched).Payload["roomName"]; other != room {
+		t.Fatalf("alice went to %v and bob to %v", room, other)
+	}
+	state := bob.await(msgGameState, nil).Payload
+	if state["status"] != game.StatusInProgress {
+		t.Errorf("matched game is %v", state["status"])
+	}
+}
+
+func TestQueueStaysOnItsInstance(t *testing.T) {
+	kv, bus := newMemKV(), newMemBus()
+	first, second := testServer(t, kv, bus), testServer(t, kv, bus)
+	alice, bob := connect(t, first, "alice", ""), connect(t, second, "bob", "")
+	alice.joined(lobbyName)
+	bob.joined(lobbyName)
+
+	queue := map[string]any{"action": "join", "gameName": "tictactoe"}
+	alice.send(msgQueue, queue)
+	alice.await(msgQueue, func(p map[string]any) bool { return p["status"] == "queued" })
+	bob.send(msgQueue, queue)
+	if status := bob.await(msgQueue, nil).Payload["status"]; status != "queued" {
+		t.Errorf("bob on another instance was %v, want queued", status)
+	}
+}
@@ -18,6 +18,16 @@ const (
 	msgLeaveRoom  = "leave_room"
 	msgGetRooms   = "get_rooms"
 	msgGetClients = "get_clients"
+	msgQueue      = "queue"
+	msgSession    = "session"
+	msgRoomUpdate = "room_update"
+	msgInvite     = "invite"
+
+	// For a room's host
+	msgKick          = "kick"
+	msgBan           = "ban"
+	msgMute          = "mute"
+	msgTransferOwner = "transfer_owner"
 )
 
 type roomMsg struct {
@@ -25,21 +35,45 @@ type roomMsg struct {
 	Sender  string          `json:"sender,omitempty"`
 	Client  *client         `json:"-"`
 	Payload json.RawMessage `json:"payload,omitempty"`
+	Seq     int64           `json:"seq,omitempty"` // of a room broadcast
 }
 
 type GameMessagePayload struct {
 	Action   string         `json:"action"`
 	GameName string         `json:"gameName,omitempty"`
+	Options  *game.Options  `json:"options,omitempty"`
+	Bot      string         `json:"bot,omitempty"` // difficulty of a bot opponent, on create
+	GameID   string         `json:"gameId,omitempty"`
+	Interval int            `json:"interval,omitempty"` // ms between replayed moves
 	Move     *game.GameMove `json:"move,omitempty"`
+	Seat     *int           `json:"seat,omitempty"` // seat asked for on create or join
+}
+
+// seat is the seat asked for, or -1 for any.
+func (p *GameMessagePayload) seat() int {
+	if p.Seat == nil {
+		return -1
+	}
+	return *p.Seat
 }
 
 type JoinRoomPayload struct {
-	RoomName string `json:"roomName"`
+	RoomName   string `json:"roomName"`
+	Password   string `json:"password,omitempty"` // to enter, or to set on a room the join opens
+	Invite     string `json:"invite,omitempty"`
+	Title      string `json:"title,omitempty"` // this and the rest apply if the join opens the room
+	Visibility string `json:"visibility,omitempty"`
+	Capacity   int    `json:"capacity,omitempty"`
+	InviteOnly bool   `json:"inviteOnly,omitempty"`
+
+	key string // of the room, from the session of a client coming back
 }
 
 type crPair struct {
 	Client   *client
 	RoomName string
+	Settings *JoinRoomPayload
+	Owner    string // of the room, found before the hub gets the pair
 }
 
 func internalError(err error) []byte {
@@ -66,6 +100,20 @@ func sendKeyVal(msgType string, keyVal ...any) []byte {
 	return sendBytes(msgType, payloadBytes)
 }
 
+// withSeq numbers a broadcast message.
+func withSeq(msg []byte, seq int64) []byte {
+	var m roomMsg
+	if err := json.Unmarshal(msg, &m); err != nil {
+		return internalError(err)
+	}
+	m.Seq = seq
+	out, err := json.Marshal(&m)
+	if err != nil {
+		return internalError(err)
+	}
+	return out
+}
+
 func sendBytes(msgType string, bytes []byte) []byte {
 	msg := &roomMsg{
 		Type:    msgType,
@@ -0,0 +1,170 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gonext/internal/config"
+	"gonext/internal/repo"
+)
+
+type ModeratePayload struct {
+	User    string `json:"user"`
+	Persist bool   `json:"persist,omitempty"` // ban: also once the room has closed
+	Undo    bool   `json:"undo,omitempty"`    // lift a ban or mute
+}
+
+// kickOrder asks the hub to send a user's connections in a room back to the
+// lobby, telling them msg.
+type kickOrder struct {
+	room string
+	user string
+	msg  []byte
+}
+
+// bans keeps who may not enter a room in Redis, where every instance can
+// check. A ban lasts while the room stays open, under its key, or for
+// BanTTL under its name.
+type bans struct {
+	kv  repo.KVStore
+	cfg *config.WS
+}
+
+func (b *bans) add(ctx context.Context, info *roomInfo, user string, persist bool) error {
+	scope := info.Key
+	if persist {
+		scope = info.Name
+	}
+	if err := b.kv.ListAdd(ctx, fmt.Sprintf(b.cfg.BanKey, scope), user, b.cfg.BanTTL); err != nil {
+		return fmt.Errorf("bans: failed to ban user: %w", err)
+	}
+	return nil
+}
+
+func (b *bans) lift(ctx context.Context, info *roomInfo, user string) error {
+	err := errors.Join(
+		b.kv.ListDel(ctx, fmt.Sprintf(b.cfg.BanKey, info.Key), user),
+		b.kv.ListDel(ctx, fmt.Sprintf(b.cfg.BanKey, info.Name), user),
+	)
+	if err != nil {
+		return fmt.Errorf("bans: failed to lift ban: %w", err)
+	}
+	return nil
+}
+
+// has reports whether user is banned from the room; info is nil for a room
+// not open anywhere.
+func (b *bans) has(ctx context.Context, name string, info *roomInfo, user string) (bool, error) {
+	scopes := []string{name}
+	if info != nil {
+		scopes = append(scopes, info.Key)
+	}
+	for _, scope := range scopes {
+		banned, err := b.kv.ListCheck(ctx, fmt.Sprintf(b.cfg.BanKey, scope), user)
+		if err != nil {
+			return false, fmt.Errorf("bans: failed to check user: %w", err)
+		}
+		if banned {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// handleModerate carries out a host's command, on the instance running the
+// room.
+func (r *room) handleModerate(client *client, msgType string, payload *ModeratePayload) {
+	if r.forward(client, msgType, payload) {
+		return
+	}
+	if err := r.moderate(client, msgType, payload); err != nil {
+		client.trySend(sendMessage(msgError, "Cannot "+strings.ReplaceAll(msgType, "_", " ")+": "+err.Error()))
+	}
+}
+
+func (r *room) moderate(client *client, msgType string, p *ModeratePayload) error {
+	info := r.index.local(r.name)
+	switch {
+	case info == nil:
+		return errors.New(r.name + " has no host")
+	case info.Host != client.ID:
+		return errors.New("only the room's host can")
+	case p.User == "" || p.User == client.ID:
+		return errors.New("pick another user")
+	}
+	by := " by " + client.user.Displayname
+
+	switch msgType {
+	case msgKick:
+		if !r.has(p.User) {
+			return errors.New(p.User + " is not in the room")
+		}
+		r.kick(p.User, "You were removed from "+r.name+by)
+		r.announce(sendMessage(msgStatus, p.User+" was removed"+by))
+
+	case msgBan:
+		ctx, cancel := context.WithTimeout(context.Background(), r.cfg.WriteTimeout)
+		defer cancel()
+		if p.Undo {
+			if err := r.bans.lift(ctx, info, p.User); err != nil {
+				return err
+			}
+			r.announce(sendMessage(msgStatus, p.User+" may come back"))
+			return nil
+		}
+		if err := r.bans.add(ctx, info, p.User, p.Persist); err != nil {
+			return err
+		}
+		if r.has(p.User) {
+			r.kick(p.User, "You were banned from "+r.name+by)
+		}
+		r.announce(sendMessage(msgStatus, p.User+" was banned"+by))
+
+	case msgMute:
+		if !r.has(p.User) {
+			return errors.New(p.User + " is not in the room")
+		}
+		r.mu.Lock()
+		if p.Undo {
+			delete(r.muted, p.User)
+		} else {
+			r.muted[p.User] = struct{}{}
+		}
+		r.mu.Unlock()
+		if p.Undo {
+			r.announce(sendMessage(msgStatus, p.User+" may chat again"))
+		} else {
+			r.announce(sendMessage(msgStatus, p.User+" was muted"+by))
+		}
+
+	case msgTransferOwner:
+		if !r.has(p.User) {
+			return errors.New(p.User + " is not in the room")
+		}
+		r.index.update(r.name, func(info *roomInfo) { info.Host = p.User })
+		r.announce(sendMessage(msgStatus, p.User+" is now the host of "+r.name))
+	}
+	return nil
+}
+
+func (r *room) kick(user, msg string) {
+	r.kicks <- &kickOrder{room: r.name, user: user, msg: sendMessage(msgStatus, msg)}
+}
+
+// evict carries out a kick order for the connections here, and passes it on
+// to the instances the user is also connected through.
+func (h *hub) evict(order *kickOrder) {
+	for c := range h.users[order.user] {
+		if c.room.name == order.room {
+			c.trySend(order.msg)
+			h.moveClient(c, h.lobby, "")
+		}
+	}
+	for key := range h.proxies {
+		if key.room == order.room && key.client == order.user {
+			h.cluster.send(key.node, &busMsg{Kind: busKick, Room: order.room, Client: order.user, Msg: order.msg})
+		}
+	}
+}
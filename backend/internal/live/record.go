@@ -0,0 +1,134 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gonext/internal/game"
+	"gonext/internal/model"
+	"gonext/internal/rating"
+	"gonext/internal/repo"
+	"gonext/internal/token"
+)
+
+const saveTimeout = 5 * time.Second
+
+// recorder writes finished games and their rating changes to the database,
+// and snapshots of running ones to Redis.
+type recorder struct {
+	games   repo.GameRepo
+	ratings repo.RatingRepo
+	snaps   *snapshotter
+}
+
+type ratingInfo struct {
+	Rating      int  `json:"rating"`
+	Provisional bool `json:"provisional,omitempty"`
+}
+
+func toRatingInfo(r *model.Rating) ratingInfo {
+	return ratingInfo{Rating: r.Rating, Provisional: rating.Provisional(r)}
+}
+
+func (rec *recorder) loadRatings(ctx context.Context, user *token.UserPayload) map[string]ratingInfo {
+	infos := make(map[string]ratingInfo)
+	if user.AccountType == model.AccountTypeGuest {
+		return infos
+	}
+	ratings, err := rec.ratings.ReadRatings(ctx, user.Username)
+	if err != nil {
+		slog.Error("loadRatings: failed to read ratings", "error", err, "user", user.Username)
+		return infos
+	}
+	for _, r := range ratings {
+		infos[r.GameName] = toRatingInfo(r)
+	}
+	return infos
+}
+
+// save stores a finished game and, for games won or drawn between two
+// registered accounts, returns the players' new ratings.
+func (rec *recorder) save(record *game.Record) []*model.Rating {
+	ctx, cancel := context.WithTimeout(context.Background(), saveTimeout)
+	defer cancel()
+
+	gameRecord, err := toGameRecord(record)
+	if err != nil {
+		slog.Error("recorder: failed to encode game", "error", err, "gameID", record.ID)
+	} else if err := rec.games.CreateGame(ctx, gameRecord); err != nil {
+		slog.Error("recorder: failed to save game", "error", err, "gameID", record.ID)
+	}
+
+	if len(record.Players) != 2 || record.Winner == "" && !record.Drawn() {
+		return nil
+	}
+	ratings, err := rec.ratings.UpdateRatings(ctx, record.GameName, record.Players,
+		func(ratings []*model.Rating) {
+			rating.Apply(ratings[0], ratings[1], rating.Score(record.Winner, record.Players[0]))
+		})
+	if err != nil {
+		if !errors.Is(err, repo.ErrNotFound) {
+			slog.Error("recorder: failed to update ratings", "error", err, "gameID", record.ID)
+		}
+		return nil
+	}
+	return ratings
+}
+
+func toGameRecord(rec *game.Record) (*model.GameRecord, error) {
+	var options json.RawMessage
+	if rec.Options != nil {
+		var err error
+		if options, err = json.Marshal(rec.Options); err != nil {
+			return nil, err
+		}
+	}
+	moves := make([]model.GameMoveRecord, 0, len(rec.Moves))
+	for i, mv := range rec.Moves {
+		move, err := json.Marshal(mv.Move)
+		if err != nil {
+			return nil, err
+		}
+		moves = append(moves, model.GameMoveRecord{
+			Ply:      i + 1,
+			Player:   mv.Player,
+			Move:     move,
+			PlayedAt: mv.At,
+		})
+	}
+	return &model.GameRecord{
+		ID:        rec.ID,
+		GameName:  rec.GameName,
+		Players:   rec.Players,
+		Winner:    rec.Winner,
+		Winners:   rec.Winners,
+		Reason:    rec.Reason,
+		Options:   options,
+		StartedAt: rec.StartedAt,
+		EndedAt:   rec.EndedAt,
+		Moves:     moves,
+	}, nil
+}
+
+// saveRecord stores a finished game and pushes any rating changes to the
+// players still in the room.
+func (r *room) saveRecord(record *game.Record) {
+	ratings := r.rec.save(record)
+	if len(ratings) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for client := range r.clients {
+		for _, updated := range ratings {
+			if client.ID == updated.Username && client.ratings != nil {
+				client.ratings[updated.GameName] = toRatingInfo(updated)
+			}
+		}
+	}
+	r.broadcastLocked(r.clientListMsgLocked())
+}
@@ -0,0 +1,42 @@
+package live
+
+import (
+	"context"
+	"testing"
+
+	"gonext/internal/game"
+	"gonext/internal/model"
+)
+
+// countedRatings counts the games it is asked to rate.
+type countedRatings struct {
+	noRatings
+	rated int
+}
+
+func (c *countedRatings) UpdateRatings(ctx context.Context, gameName string, users []string, update func([]*model.Rating)) ([]*model.Rating, error) {
+	c.rated++
+	ratings := []*model.Rating{{Rating: 1500}, {Rating: 1500}}
+	update(ratings)
+	return ratings, nil
+}
+
+func TestSaveRatesResolvedGames(t *testing.T) {
+	for _, tc := range []struct {
+		winner, reason string
+		rated          bool
+	}{
+		{"a", game.ReasonResignation, true},
+		{"", game.ReasonAgreement, true},
+		{"", game.ReasonTimeout, true},
+		{"", game.ReasonAbandoned, false},
+		{"", "", false},
+	} {
+		ratings := &countedRatings{}
+		rec := &recorder{games: noGames{}, ratings: ratings}
+		rec.save(&game.Record{ID: "g", GameName: "chess", Players: []string{"a", "b"}, Winner: tc.winner, Reason: tc.reason})
+		if rated := ratings.rated == 1; rated != tc.rated {
+			t.Errorf("winner %q by %q: rated %v, want %v", tc.winner, tc.reason, rated, tc.rated)
+		}
+	}
+}
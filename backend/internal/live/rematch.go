@@ -0,0 +1,87 @@
+package live
+
+import (
+	"errors"
+	"slices"
+
+	"gonext/internal/bot"
+	"gonext/internal/game"
+)
+
+// roomGameState is the game state plus what the room keeps across games.
+type roomGameState struct {
+	*game.GameState
+	Series  *series  `json:"series,omitempty"`
+	Rematch []string `json:"rematch,omitempty"`
+}
+
+type series struct {
+	Scores map[string]int `json:"scores"`
+	Draws  int            `json:"draws"`
+	Games  int            `json:"games"`
+
+	recorded int
+}
+
+func newSeries() *series {
+	return &series{Scores: make(map[string]int)}
+}
+
+// record counts a finished game once, however many updates it sends.
+func (s *series) record(gen int, state *game.GameState) {
+	if s == nil || s.recorded == gen {
+		return
+	}
+	s.recorded = gen
+	s.Games++
+	for _, player := range state.Players {
+		if _, ok := s.Scores[player]; !ok {
+			s.Scores[player] = 0
+		}
+	}
+	if len(state.Winners) == 0 {
+		s.Draws++
+	}
+	for _, winner := range state.Winners {
+		s.Scores[winner]++
+	}
+}
+
+// acceptRematchLocked marks player as ready for another game. Once every
+// player is in, the same game starts over with the seats rotated by one.
+func (r *room) acceptRematchLocked(player string) error {
+	state := r.game.GetState()
+	if state.Status != game.StatusFin {
+		return errors.New("game not finished")
+	}
+	if !slices.Contains(state.Players, player) {
+		return errors.New("player not in game")
+	}
+	if len(state.Players) < 2 {
+		return errors.New("no one left to play")
+	}
+	// The update that finished the game may not have reached the room yet.
+	r.series.record(r.gameGen, state)
+	r.rematch[player] = struct{}{}
+	if r.bot != nil {
+		r.rematch[bot.Name] = struct{}{}
+	}
+	for _, p := range state.Players {
+		if _, ok := r.rematch[p]; !ok {
+			r.broadcastStateLocked(r.game.StateFor)
+			return nil
+		}
+	}
+
+	if err := r.newGameLocked(r.gameName, r.gameOpts); err != nil {
+		return err
+	}
+	seats := slices.Concat(state.Players[1:], state.Players[:1])
+	for _, p := range seats {
+		if err := r.game.Join(p); err != nil {
+			return err
+		}
+	}
+	r.game.Start()
+	return nil
+}
@@ -0,0 +1,149 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"gonext/internal/game"
+	"gonext/internal/match"
+	"gonext/internal/repo"
+)
+
+const (
+	defaultReplayInterval = time.Second
+	minReplayInterval     = 100 * time.Millisecond
+	maxReplayInterval     = 10 * time.Second
+)
+
+// liveGames finds the games being played in any room by their ID.
+type liveGames struct {
+	mu    sync.RWMutex
+	games map[string]game.Game
+}
+
+func newLiveGames() *liveGames {
+	return &liveGames{games: make(map[string]game.Game)}
+}
+
+func (l *liveGames) add(id string, g game.Game) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.games[id] = g
+}
+
+func (l *liveGames) remove(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.games, id)
+}
+
+func (l *liveGames) get(id string) game.Game {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.games[id]
+}
+
+// replay is a game being played back into a room in place of a live one.
+type replay struct {
+	state  *game.GameState
+	cancel context.CancelFunc
+}
+
+// loadRecord finds the game with id, played so far if it is still going
+// and user is one of its players.
+func (r *room) loadRecord(ctx context.Context, id, user string) (*game.Record, error) {
+	if g := r.live.get(id); g != nil {
+		rec := g.Record()
+		if rec == nil || !rec.VisibleTo(user) {
+			return nil, errors.New("game not found")
+		}
+		return rec, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, saveTimeout)
+	defer cancel()
+	stored, err := r.rec.games.ReadGame(ctx, id)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, errors.New("game not found")
+		}
+		return nil, err
+	}
+	rec, err := match.ToRecord(stored)
+	if err != nil {
+		return nil, err
+	}
+	if !rec.VisibleTo(user) {
+		return nil, errors.New("game not found")
+	}
+	return rec, nil
+}
+
+func (r *room) startReplay(client *client, payload *GameMessagePayload) error {
+	rec, err := r.loadRecord(client.ctx, payload.GameID, client.ID)
+	if err != nil {
+		return err
+	}
+	states, err := r.registry.Replay(rec)
+	if err != nil {
+		return err
+	}
+	interval := defaultReplayInterval
+	if payload.Interval > 0 {
+		interval = min(max(time.Duration(payload.Interval)*time.Millisecond, minReplayInterval), maxReplayInterval)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.game != nil || r.replay != nil {
+		return errors.New("room is busy")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.replay = &replay{cancel: cancel}
+	r.series = nil
+	go r.playReplay(ctx, r.replay, states, interval)
+	return nil
+}
+
+// playReplay shows one state per interval, then leaves the last one up as
+// long as a finished game would stay.
+func (r *room) playReplay(ctx context.Context, rp *replay, states []*game.GameState, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for i, state := range states {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+		r.mu.Lock()
+		if r.replay != rp {
+			r.mu.Unlock()
+			return
+		}
+		rp.state = state
+		r.broadcastStateLocked(func(string) *game.GameState { return state })
+		r.mu.Unlock()
+	}
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(game.CleanupDelay):
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.replay == rp {
+		r.stopReplayLocked()
+	}
+}
+
+func (r *room) stopReplayLocked() {
+	r.replay.cancel()
+	r.replay = nil
+	r.broadcastLocked([]byte(cleanStateMsg))
+}
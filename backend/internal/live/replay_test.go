@@ -0,0 +1,41 @@
+package live
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// startQueuedGame pairs alice and bob at tictactoe and returns the game's ID.
+func startQueuedGame(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	alice, bob := connect(t, srv, "alice", ""), connect(t, srv, "bob", "")
+	alice.joined(lobbyName)
+	bob.joined(lobbyName)
+	queue := map[string]any{"action": "join", "gameName": "tictactoe"}
+	alice.send(msgQueue, queue)
+	alice.await(msgQueue, func(p map[string]any) bool { return p["status"] == "queued" })
+	bob.send(msgQueue, queue)
+	return bob.await(msgGameState, nil).Payload["id"].(string)
+}
+
+func TestReplayRunningGame(t *testing.T) {
+	srv := testServer(t, newMemKV(), newMemBus())
+	id := startQueuedGame(t, srv)
+	replay := map[string]any{"action": "replay", "gameId": id}
+
+	carol := connect(t, srv, "carol", "")
+	carol.joined(lobbyName)
+	carol.send(msgJoinRoom, map[string]any{"roomName": "c"})
+	carol.joined("c")
+	carol.send(msgGameState, replay)
+	carol.await(msgError, func(p map[string]any) bool { return p["message"] == "Cannot replay game: game not found" })
+
+	alice := connect(t, srv, "alice", "")
+	alice.joined(lobbyName)
+	alice.send(msgJoinRoom, map[string]any{"roomName": "a"})
+	alice.joined("a")
+	alice.send(msgGameState, replay)
+	if state := alice.await(msgGameState, nil).Payload; state["id"] != id {
+		t.Errorf("alice replayed %v, want her game %s", state["id"], id)
+	}
+}
@@ -2,57 +2,196 @@ package live
 
 import (
 	"encoding/json"
+	"log/slog"
+	"maps"
+	"slices"
 	"sync"
+	"time"
 
+	"gonext/internal/bot"
+	"gonext/internal/config"
 	"gonext/internal/game"
+	"gonext/internal/webrtc"
 )
 
 const (
 	errorMsg      = `{"type":"error","sender":"_server","payload":"Game state corrupted, resetting..."}`
-	cleanStateMsg = `{"type":"game_state","sender":"_server","payload":{"gameName":"","status":"waiting","players":[],"turn":0,"board":[[]],"winner":"","validMoves":[]}}`
+	cleanStateMsg = `{"type":"game_state","sender":"_server","payload":{"gameName":"","status":"waiting","players":[],"seats":0,"turn":0,"board":[[]],"winner":"","validMoves":[],"spectators":[]}}`
 )
 
+// noResume is the since of a client joining afresh rather than coming back.
+const noResume = -1
+
+// logged is a numbered broadcast, kept for clients coming back.
+type logged struct {
+	seq   int64
+	all   []byte
+	views map[string][]byte // for whoever saw something other than all
+}
+
+func (e *logged) forClient(id string) []byte {
+	if msg, ok := e.views[id]; ok {
+		return msg
+	}
+	return e.all
+}
+
+// logLocked numbers the next broadcast and keeps it, dropping the oldest
+// past ResumeLog. The caller also holds logMu.
+func (r *room) logLocked() *logged {
+	r.seq++
+	entry := &logged{seq: r.seq}
+	r.log = append(r.log, entry)
+	if len(r.log) > r.cfg.ResumeLog {
+		r.log = slices.Delete(r.log, 0, len(r.log)-r.cfg.ResumeLog)
+	}
+	return entry
+}
+
 func (r *room) broadcastLocked(msg []byte) {
+	r.logMu.Lock()
+	defer r.logMu.Unlock()
+	entry := r.logLocked()
+	entry.all = withSeq(msg, entry.seq)
+	for client := range r.clients {
+		client.trySend(entry.all)
+	}
+}
+
+// broadcastStateLocked sends every client the game state as view shows it to
+// them. Players who are away get theirs logged for when they come back.
+func (r *room) broadcastStateLocked(view func(viewer string) *game.GameState) {
+	r.logMu.Lock()
+	defer r.logMu.Unlock()
+	entry := r.logLocked()
+	public := view("")
+	entry.all = withSeq(r.sendGameState(public), entry.seq)
+	entry.views = make(map[string][]byte)
 	for client := range r.clients {
-		client.trySend(msg)
+		if _, ok := entry.views[client.ID]; !ok {
+			entry.views[client.ID] = withSeq(r.sendGameState(view(client.ID)), entry.seq)
+		}
+		client.trySend(entry.views[client.ID])
+	}
+	for _, player := range public.Players {
+		if _, ok := entry.views[player]; !ok {
+			entry.views[player] = withSeq(r.sendGameState(view(player)), entry.seq)
+		}
+	}
+}
+
+// coversLocked reports whether the log holds everything broadcast after
+// since. The caller also holds logMu.
+func (r *room) coversLocked(since int64) bool {
+	if since < 0 || since > r.seq {
+		return false
+	}
+	return since == r.seq || len(r.log) > 0 && r.log[0].seq <= since+1
+}
+
+// greetLocked tells client it is in the room. A client coming back also gets
+// everything it missed since, if the room still has it.
+func (r *room) greetLocked(client *client, since int64) {
+	r.logMu.Lock()
+	resumed := r.coversLocked(since)
+	client.trySend(sendKeyVal(msgJoinRoom, "roomName", r.name, "seq", r.seq, "resumed", resumed))
+	if resumed {
+		for _, entry := range r.log {
+			if entry.seq > since {
+				client.trySend(entry.forClient(client.ID))
+			}
+		}
+	}
+	r.logMu.Unlock()
+	if r.game != nil {
+		r.game.Rejoin(client.ID)
 	}
 }
 
-func (r *room) clientListMsgLocked() map[string]string {
+func (r *room) clientListMsgLocked() []byte {
 	clientMap := make(map[string]string, len(r.clients))
+	ratingMap := make(map[string]map[string]ratingInfo, len(r.clients))
 	for client := range r.clients {
 		clientMap[client.ID] = client.user.Displayname
+		ratingMap[client.ID] = client.ratings
 	}
-	return clientMap
+	return sendKeyVal(msgGetClients, "clients", clientMap, "ratings", ratingMap)
 }
 
-func (r *room) addClient(client *client) {
+// addClient puts client in the room. since is the last message a client
+// coming back saw here, or noResume.
+func (r *room) addClient(client *client, since int64) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	r.clients[client] = struct{}{}
 	client.room = r
-	client.trySend(sendKeyVal(msgJoinRoom, "roomName", r.name))
+	client.arrived = time.Now()
+	if r.owner != "" {
+		// The owner greets the client and sends it everything from now on.
+		if since != noResume || r.countLocked(client.ID) == 1 {
+			r.joinOwnerLocked(client, since)
+		}
+		return
+	}
+	r.greetLocked(client, since)
+	if len(r.call.Members()) > 0 {
+		r.sendRosterLocked(client)
+	}
 
-	r.broadcastLocked(sendMessage(msgStatus, client.user.Displayname+" has joined "+r.name))
-	r.broadcastLocked(sendKeyVal(msgGetClients,
-		"clients", r.clientListMsgLocked()))
+	if r.countLocked(client.ID) == 1 {
+		r.broadcastLocked(sendMessage(msgStatus, client.user.Displayname+" has joined "+r.name))
+		r.publishUsersLocked()
+	}
+	r.broadcastLocked(r.clientListMsgLocked())
 }
 
 type room struct {
 	registry *game.Registry
+	rec      *recorder
+	live     *liveGames
+	cluster  *cluster
+	index    *roomIndex
+	bans     *bans
+	kicks    chan<- *kickOrder
+	ended    chan<- *room // to the hub, once the game is over with no one in the room
+	cfg      *config.WS
+	owner    string // instance running the room, "" for this one; set by the hub
 	name     string
+	seq      int64 // of the last broadcast
+	log      []*logged
+	logMu    sync.Mutex // orders broadcasts, which may happen under the read lock
 	clients  map[*client]struct{}
+	muted    map[string]struct{} // users whose chat is not passed on
+	call     *webrtc.Mesh
 	mu       sync.RWMutex
 	game     game.Game
+	gameName string
+	gameOpts *game.Options
+	gameGen  int
+	series   *series
+	rematch  map[string]struct{}
+	bot      *bot.Bot // plays the other seat of every game, if set
+	driver   *botDriver
+	replay   *replay
 }
 
-func newRoom(name string, registry *game.Registry) *room {
+func newRoom(name string, h *hub) *room {
 	return &room{
 		name:     name,
 		clients:  make(map[*client]struct{}),
+		muted:    make(map[string]struct{}),
+		call:     webrtc.NewMesh(h.cfg.CallSize),
 		mu:       sync.RWMutex{},
-		registry: registry,
+		registry: h.registry,
+		rec:      h.rec,
+		live:     h.live,
+		cluster:  h.cluster,
+		index:    h.index,
+		bans:     h.bans,
+		kicks:    h.kick,
+		ended:    h.ended,
+		cfg:      h.cfg,
 	}
 }
 
@@ -60,65 +199,429 @@ func (r *room) removeClient(client *client) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, ok := r.clients[client]; ok {
-		if r.game != nil {
-			r.game.Leave(client.ID, false)
+	if _, ok := r.clients[client]; ok && r.owner != "" {
+		delete(r.clients, client)
+		if client.node == "" && r.countLocked(client.ID) == 0 {
+			r.cluster.send(r.owner, &busMsg{Kind: busLeave, Room: r.name, Client: client.ID})
 		}
+	} else if ok {
 		delete(r.clients, client)
+		// Players are only away once none of their connections are here.
+		if r.countLocked(client.ID) == 0 {
+			if r.game != nil {
+				r.game.Leave(client.ID, false)
+			}
+			r.dropFromCallLocked(client.ID)
+			r.broadcastLocked(sendMessage(msgStatus, client.user.Displayname+" has left "+r.name))
+			r.publishUsersLocked()
+		}
+		r.broadcastLocked(r.clientListMsgLocked())
+	}
+}
+
+// has reports whether a client logged in as id is in the room here.
+func (r *room) has(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.countLocked(id) > 0
+}
+
+// publishUsersLocked updates the room's user count. A room whose host is not
+// in it any more passes to whoever has been in it longest.
+func (r *room) publishUsersLocked() {
+	users := make(map[string]struct{}, len(r.clients))
+	var longest *client
+	for client := range r.clients {
+		users[client.ID] = struct{}{}
+		if longest == nil || client.arrived.Before(longest.arrived) {
+			longest = client
+		}
+	}
+	var newHost *client
+	r.index.update(r.name, func(info *roomInfo) {
+		info.Users = len(users)
+		if _, ok := users[info.Host]; !ok && longest != nil {
+			info.Host = longest.ID
+			newHost = longest
+		}
+	})
+	if newHost != nil {
+		r.broadcastLocked(sendMessage(msgStatus, newHost.user.Displayname+" is now the host of "+r.name))
+	}
+}
+
+// countLocked counts the room's clients logged in as id.
+func (r *room) countLocked(id string) int {
+	n := 0
+	for client := range r.clients {
+		if client.ID == id {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *room) joinOwnerLocked(client *client, since int64) {
+	r.cluster.send(r.owner, &busMsg{
+		Kind:    busJoin,
+		Room:    r.name,
+		Client:  client.ID,
+		User:    client.user,
+		Ratings: client.ratings,
+		Since:   since,
+	})
+}
+
+// announce sends msg to everyone in the room, wherever it runs.
+func (r *room) announce(msg []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.owner != "" {
+		r.cluster.send(r.owner, &busMsg{Kind: busAnnounce, Room: r.name, Msg: msg})
+		return
+	}
+	r.broadcastLocked(msg)
+}
+
+// resume greets a client from another instance coming back to the room.
+func (r *room) resume(client *client, since int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.greetLocked(client, since)
+}
+
+// forward passes a client's message on to the room's owner, if that is
+// another instance.
+func (r *room) forward(client *client, msgType string, payload any) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.owner == "" {
+		return false
+	}
+	data, err := json.Marshal(payload)
+	if err == nil {
+		data, err = json.Marshal(&roomMsg{Type: msgType, Payload: data})
+	}
+	if err != nil {
+		client.trySend(internalError(err))
+		return true
+	}
+	r.cluster.send(r.owner, &busMsg{Kind: busForward, Room: r.name, Client: client.ID, Msg: data})
+	return true
+}
 
-		r.broadcastLocked(sendMessage(msgStatus, client.user.Displayname+" has left "+r.name))
-		r.broadcastLocked(sendKeyVal(msgGetClients,
-			"clients", r.clientListMsgLocked()))
+// deliver sends msg from the room's owner to the clients here logged in as
+// id.
+func (r *room) deliver(id string, msg []byte) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for client := range r.clients {
+		if client.ID == id {
+			client.trySend(msg)
+		}
 	}
 }
 
+// setOwner moves the room to another instance, or takes it over here when
+// owner is "", bringing its game back from snap if there is one. Clients
+// here are introduced to the new owner either way.
+func (r *room) setOwner(owner string, snap *roomSnapshot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if owner == r.owner {
+		return
+	}
+	if r.owner == "" {
+		r.gameGen++
+		if r.game != nil {
+			r.game.Stop()
+			r.live.remove(r.game.GetState().ID)
+			r.game = nil
+		}
+		r.stopBotLocked()
+		if r.replay != nil {
+			r.replay.cancel()
+			r.replay = nil
+		}
+		r.rematch = nil
+		r.series = nil
+		r.muted = make(map[string]struct{})
+		r.call = webrtc.NewMesh(r.cfg.CallSize)
+	}
+	r.owner = owner
+
+	if owner != "" {
+		r.index.forget(r.name)
+		joined := make(map[string]struct{})
+		for client := range r.clients {
+			if _, ok := joined[client.ID]; !ok && client.node == "" {
+				joined[client.ID] = struct{}{}
+				r.joinOwnerLocked(client, noResume)
+			}
+		}
+		return
+	}
+	if snap != nil {
+		if err := r.restoreLocked(snap); err != nil {
+			slog.Error("room: failed to restore game", "error", err, "room", r.name)
+		}
+	}
+	for client := range r.clients {
+		r.greetLocked(client, noResume)
+	}
+	r.broadcastLocked(r.clientListMsgLocked())
+	r.publishUsersLocked()
+}
+
 func (r *room) handleRelay(msg *roomMsg) {
+	if r.forward(msg.Client, msg.Type, msg.Payload) {
+		return
+	}
 	jsonMsg, err := json.Marshal(msg)
 	if err != nil {
 		msg.Client.trySend(internalError(err))
 		return
 	}
 	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.muted[msg.Client.ID]; ok && msg.Type == msgChat {
+		msg.Client.trySend(sendMessage(msgError, "You are muted in "+r.name))
+		return
+	}
 	r.broadcastLocked(jsonMsg)
-	r.mu.RUnlock()
 }
 
-func (r *room) handleGameUpdate(update game.GameUpdate) {
-	switch update.Action {
+// newGameLocked replaces the room's game. Updates from games the room has
+// since moved on from are dropped.
+func (r *room) newGameLocked(name string, opts *game.Options) error {
+	return r.setGameLocked(name, opts, func(updator func(game.GameUpdate)) (game.Game, error) {
+		return r.registry.Create(name, opts, updator)
+	})
+}
+
+// restore brings back the room's game from a snapshot, waiting for its
+// players to rejoin.
+func (r *room) restore(snap *roomSnapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.restoreLocked(snap)
+}
+
+func (r *room) restoreLocked(snap *roomSnapshot) error {
+	r.bot = nil
+	if snap.Bot != "" {
+		b, err := bot.New(snap.Game.GameName, snap.Bot)
+		if err != nil {
+			return err
+		}
+		r.bot = b
+	}
+	err := r.setGameLocked(snap.Game.GameName, snap.Game.Options, func(updator func(game.GameUpdate)) (game.Game, error) {
+		return r.registry.Restore(snap.Game, updator)
+	})
+	if err != nil {
+		return err
+	}
+	r.series = snap.Series
+	if r.series == nil {
+		r.series = newSeries()
+	}
+	if r.bot != nil {
+		r.game.Rejoin(bot.Name)
+	}
+	r.game.Start()
+	return nil
+}
+
+func (r *room) setGameLocked(name string, opts *game.Options, create func(func(game.GameUpdate)) (game.Game, error)) error {
+	r.gameGen++
+	gen := r.gameGen
+	var driver *botDriver
+	if r.bot != nil {
+		driver = newBotDriver(r.bot)
+	}
+	newGame, err := create(func(update game.GameUpdate) {
+		if update.Record != nil {
+			go r.saveRecord(update.Record)
+		}
+		go r.handleGameUpdate(gen, update.Action)
+		if driver != nil {
+			driver.poke()
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if r.game != nil {
+		r.game.Stop()
+		r.live.remove(r.game.GetState().ID)
+	}
+	r.stopBotLocked()
+	if driver != nil {
+		driver.game = newGame
+		r.driver = driver
+		go driver.run()
+	}
+	r.live.add(newGame.GetState().ID, newGame)
+	r.game = newGame
+	r.gameName = name
+	r.gameOpts = opts
+	r.rematch = make(map[string]struct{})
+	return nil
+}
+
+// handleGameUpdate brings the room up to date with game gen once it has
+// changed. Games send updates from whatever goroutine changed them, some
+// holding the room lock and some not, so the room catches up on its own,
+// from the game's current state. Updates of a game the room has since moved
+// on from are dropped.
+func (r *room) handleGameUpdate(gen int, action game.GameAction) {
+	if r.applyGameUpdate(gen, action) {
+		r.ended <- r
+	}
+}
+
+// applyGameUpdate reports whether the game is over in a room no one is in,
+// as a restored one may be.
+func (r *room) applyGameUpdate(gen int, action game.GameAction) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if gen != r.gameGen || r.game == nil {
+		return false
+	}
+
+	state := r.game.GetState()
+	switch action {
 	case game.UpdateAction:
-		r.broadcastLocked(r.sendGameState(update.State))
+		r.index.update(r.name, func(info *roomInfo) {
+			info.GameName = state.GameName
+			info.Status = state.Status
+			info.Players = len(state.Players)
+		})
+		if state.Status == game.StatusFin {
+			r.series.record(gen, state)
+			r.rec.snaps.remove(r.name)
+		} else if snap := r.game.Snapshot(); snap != nil {
+			r.saveSnapshotLocked(snap)
+		}
+		r.broadcastStateLocked(r.game.StateFor)
+		return state.Status == game.StatusFin && len(r.clients) == 0
 	case game.DeleteAction:
+		r.index.update(r.name, func(info *roomInfo) {
+			info.GameName, info.Status, info.Players = "", "", 0
+		})
+		r.rec.snaps.remove(r.name)
+		r.live.remove(state.ID)
 		r.broadcastLocked([]byte(cleanStateMsg))
 		r.game = nil
+		r.rematch = nil
+		r.stopBotLocked()
+		return len(r.clients) == 0
 	}
+	return false
+}
+
+// close lets go of the game of a room the hub dropped, so that it no longer
+// publishes anything about the room.
+func (r *room) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.gameGen++
+	if r.game != nil {
+		r.game.Stop()
+		r.live.remove(r.game.GetState().ID)
+		r.game = nil
+	}
+	r.stopBotLocked()
+}
+
+// stopBotLocked lets go of the bot playing in the room's game, if any.
+func (r *room) stopBotLocked() {
+	if r.driver != nil {
+		close(r.driver.stop)
+		r.driver = nil
+	}
+}
+
+func (r *room) saveSnapshotLocked(snap *game.Snapshot) {
+	roomSnap := &roomSnapshot{Room: r.name, Series: r.series, Info: r.index.local(r.name), Game: snap}
+	if r.bot != nil {
+		roomSnap.Bot = r.bot.Difficulty()
+	}
+	r.rec.snaps.save(roomSnap)
+}
+
+var gameActions = map[string]func(game.Game, string) error{
+	"resign":           game.Game.Resign,
+	"offer_draw":       game.Game.OfferDraw,
+	"accept_draw":      game.Game.AcceptDraw,
+	"decline_draw":     game.Game.DeclineDraw,
+	"request_takeback": game.Game.RequestTakeback,
+	"accept_takeback":  game.Game.AcceptTakeback,
+	"decline_takeback": game.Game.DeclineTakeback,
 }
 
 func (r *room) handleGameState(client *client, payload *GameMessagePayload) {
+	if r.forward(client, msgGameState, payload) {
+		return
+	}
 	switch payload.Action {
 	case "get":
-		if r.game == nil {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		switch {
+		case r.game != nil:
+			client.trySend(r.sendGameState(r.game.StateFor(client.ID)))
+		case r.replay != nil && r.replay.state != nil:
+			client.trySend(r.sendGameState(r.replay.state))
+		default:
 			client.trySend([]byte(cleanStateMsg))
-		} else {
-			client.trySend(r.sendGameState(r.game.GetState()))
 		}
 	case "create":
 		r.mu.Lock()
 		defer r.mu.Unlock()
+		if r.replay != nil {
+			client.trySend(sendMessage(msgError, "Cannot create game: a replay is running"))
+			return
+		}
 		if r.game == nil {
-			newGame, err := r.registry.Create(payload.GameName, r.handleGameUpdate)
-			if err != nil {
-				client.trySend(sendMessage(msgError, "Invalid game name: "+payload.GameName))
+			r.bot = nil
+			if payload.Bot != "" {
+				b, err := bot.New(payload.GameName, payload.Bot)
+				if err != nil {
+					client.trySend(sendMessage(msgError, "Cannot create game: "+err.Error()))
+					return
+				}
+				r.bot = b
+			}
+			if err := r.newGameLocked(payload.GameName, payload.Options); err != nil {
+				client.trySend(sendMessage(msgError, "Cannot create game: "+err.Error()))
 				return
 			}
-			r.game = newGame
-			r.game.Join(client.ID)
+			r.series = newSeries()
+			if err := r.game.JoinSeat(client.ID, payload.seat()); err != nil {
+				client.trySend(sendMessage(msgError, err.Error()))
+			}
+			if r.bot != nil {
+				r.game.Join(bot.Name)
+			}
 			r.game.Start()
 		}
 	case "join":
 		r.mu.RLock()
 		defer r.mu.RUnlock()
 		if r.game != nil {
-			if err := r.game.Join(client.ID); err != nil {
+			if err := r.game.JoinSeat(client.ID, payload.seat()); err != nil {
+				client.trySend(sendMessage(msgError, err.Error()))
+			}
+		}
+	case "spectate":
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		if r.game != nil {
+			if err := r.game.Spectate(client.ID); err != nil {
 				client.trySend(sendMessage(msgError, err.Error()))
 			}
 		}
@@ -130,6 +633,33 @@ func (r *room) handleGameState(client *client, payload *GameMessagePayload) {
 				client.trySend(sendMessage(msgError, err.Error()))
 			}
 		}
+	case "resign", "offer_draw", "accept_draw", "decline_draw",
+		"request_takeback", "accept_takeback", "decline_takeback":
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		if r.game != nil {
+			if err := gameActions[payload.Action](r.game, client.ID); err != nil {
+				client.trySend(sendMessage(msgError, err.Error()))
+			}
+		}
+	case "rematch":
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.game != nil {
+			if err := r.acceptRematchLocked(client.ID); err != nil {
+				client.trySend(sendMessage(msgError, err.Error()))
+			}
+		}
+	case "replay":
+		if err := r.startReplay(client, payload); err != nil {
+			client.trySend(sendMessage(msgError, "Cannot replay game: "+err.Error()))
+		}
+	case "stop_replay":
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.replay != nil {
+			r.stopReplayLocked()
+		}
 	case "leave":
 		r.mu.RLock()
 		defer r.mu.RUnlock()
@@ -142,7 +672,11 @@ func (r *room) handleGameState(client *client, payload *GameMessagePayload) {
 }
 
 func (r *room) sendGameState(state *game.GameState) []byte {
-	stateBytes, err := json.Marshal(state)
+	stateBytes, err := json.Marshal(&roomGameState{
+		GameState: state,
+		Series:    r.series,
+		Rematch:   slices.Sorted(maps.Keys(r.rematch)),
+	})
 	if err != nil {
 		return r.panicReset()
 	}
@@ -154,6 +688,7 @@ func (r *room) panicReset() []byte {
 	defer r.mu.Unlock()
 
 	r.game = nil
+	r.stopBotLocked()
 	r.broadcastLocked([]byte(errorMsg))
 	r.broadcastLocked([]byte(cleanStateMsg))
 	return []byte(cleanStateMsg)
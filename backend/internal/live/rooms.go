@@ -0,0 +1,245 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"gonext/internal/config"
+	"gonext/internal/repo"
+
+	"github.com/google/uuid"
+)
+
+// Who can find a room.
+const (
+	roomPublic   = "public"   // listed in get_rooms and the lobby
+	roomUnlisted = "unlisted" // joined by name only
+)
+
+const maxTitleLen = 64
+
+// roomInfo describes a room to those looking for one. What lets people in
+// is kept alongside, but only sent to clients through public.
+type roomInfo struct {
+	Name       string `json:"name"`
+	Title      string `json:"title"`
+	Visibility string `json:"visibility"`
+	Access     string `json:"access"`
+	Host       string `json:"host,omitempty"` // user who opened the room
+	Capacity   int    `json:"capacity"`       // users the room takes, 0 for any number
+	Users      int    `json:"users"`
+	GameName   string `json:"gameName,omitempty"`
+	Status     string `json:"status,omitempty"` // of the game
+	Players    int    `json:"players"`          // seated in the game
+	Key        string `json:"key,omitempty"`    // new each time the room opens; salts the password, binds invites
+	PassHash   string `json:"passHash,omitempty"`
+}
+
+func (i roomInfo) public() *roomInfo {
+	i.Key, i.PassHash = "", ""
+	return &i
+}
+
+func (i *roomInfo) full() bool {
+	return i.Capacity > 0 && i.Users >= i.Capacity
+}
+
+type GetRoomsPayload struct {
+	GameName string `json:"gameName,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Search   string `json:"search,omitempty"` // in titles, ignoring case
+	Open     bool   `json:"open,omitempty"`   // only rooms with space left
+}
+
+func (p *GetRoomsPayload) matches(info *roomInfo) bool {
+	return info.Visibility == roomPublic &&
+		(p.GameName == "" || info.GameName == p.GameName) &&
+		(p.Status == "" || info.Status == p.Status) &&
+		(p.Search == "" || strings.Contains(strings.ToLower(info.Title), strings.ToLower(p.Search))) &&
+		(!p.Open || !info.full())
+}
+
+// settings checks what a join asks of the room it opens.
+func (p *JoinRoomPayload) settings() error {
+	p.Title = strings.TrimSpace(p.Title)
+	switch {
+	case utf8.RuneCountInString(p.Title) > maxTitleLen:
+		return errors.New("title too long")
+	case p.Visibility != "" && p.Visibility != roomPublic && p.Visibility != roomUnlisted:
+		return errors.New("unknown visibility: " + p.Visibility)
+	case p.Capacity < 0:
+		return errors.New("capacity cannot be negative")
+	case len(p.Password) > maxPasswordLen:
+		return errors.New("password too long")
+	}
+	return nil
+}
+
+// apply sets up the room the host's join opened.
+func (p *JoinRoomPayload) apply(info *roomInfo, host string) {
+	if p.Title != "" {
+		info.Title = p.Title
+	}
+	if p.Visibility != "" {
+		info.Visibility = p.Visibility
+	}
+	info.Capacity = p.Capacity
+	info.Host = host
+	switch {
+	case p.InviteOnly:
+		info.Access = accessInvite
+	case p.Password != "":
+		info.Access = accessPassword
+		info.PassHash = hashPassword(info.Key, p.Password)
+	}
+}
+
+// roomIndex publishes the rooms this instance runs, so that every instance
+// can list them. Changes to public rooms are announced in the lobby.
+type roomIndex struct {
+	w        *kvWriter
+	mu       sync.Mutex
+	rooms    map[string]*roomInfo // run here
+	announce func(msg []byte)     // set by the hub before any room opens
+}
+
+// Room infos expire unless the instance running the room keeps them fresh,
+// so rooms of an instance that went away drop out of the list.
+func newRoomIndex(kv repo.KVStore, cfg *config.WS) *roomIndex {
+	return &roomIndex{
+		w:     newKVWriter(kv, "roomIndex", cfg.RoomInfoKey, cfg.RoomIndex, cfg.ClusterTTL),
+		rooms: make(map[string]*roomInfo),
+	}
+}
+
+// update changes what is published about a room run here. The lobby is
+// never listed, which lets rooms announce to it while holding their locks.
+func (x *roomIndex) update(name string, change func(info *roomInfo)) {
+	if name == lobbyName {
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	info, ok := x.rooms[name]
+	if !ok {
+		info = &roomInfo{Name: name, Title: name, Visibility: roomPublic, Access: accessOpen, Key: uuid.NewString()}
+		x.rooms[name] = info
+	}
+	old := *info
+	change(info)
+	if ok && old == *info {
+		return
+	}
+	x.w.put(name, info)
+	if info.Visibility == roomPublic {
+		x.announce(sendKeyVal(msgRoomUpdate, "room", info.public()))
+	} else if ok && old.Visibility == roomPublic {
+		x.announce(sendKeyVal(msgRoomUpdate, "name", name, "removed", true))
+	}
+}
+
+// adopt carries on publishing a room taken over from another instance.
+func (x *roomIndex) adopt(info *roomInfo) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if _, ok := x.rooms[info.Name]; !ok && info.Name != lobbyName {
+		x.rooms[info.Name] = info
+	}
+}
+
+// forget stops publishing a room now run elsewhere.
+func (x *roomIndex) forget(name string) {
+	if name == lobbyName {
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	delete(x.rooms, name)
+}
+
+// remove takes down a room closed here.
+func (x *roomIndex) remove(name string) {
+	if name == lobbyName {
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	info, ok := x.rooms[name]
+	if !ok {
+		return
+	}
+	delete(x.rooms, name)
+	x.w.remove(name)
+	if info.Visibility == roomPublic {
+		x.announce(sendKeyVal(msgRoomUpdate, "name", name, "removed", true))
+	}
+}
+
+// refresh rewrites the rooms run here before they expire.
+func (x *roomIndex) refresh() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for name, info := range x.rooms {
+		x.w.put(name, info)
+	}
+}
+
+// local returns a room run here, or nil.
+func (x *roomIndex) local(name string) *roomInfo {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if info, ok := x.rooms[name]; ok {
+		copied := *info
+		return &copied
+	}
+	return nil
+}
+
+// get returns what is known of a room, or nil if it is not open anywhere.
+func (x *roomIndex) get(ctx context.Context, name string) *roomInfo {
+	if info := x.local(name); info != nil {
+		return info
+	}
+	info := &roomInfo{}
+	if err := x.w.read(ctx, name, info); err != nil {
+		return nil
+	}
+	return info
+}
+
+// list returns the public rooms on every instance that match filter, by
+// name.
+func (x *roomIndex) list(ctx context.Context, filter *GetRoomsPayload) ([]*roomInfo, error) {
+	names, err := x.w.members(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(names)
+	rooms := make([]*roomInfo, 0, len(names))
+	for _, name := range names {
+		if info := x.get(ctx, name); info != nil && filter.matches(info) {
+			rooms = append(rooms, info.public())
+		}
+	}
+	return rooms, nil
+}
+
+// sendRooms answers a get_rooms request.
+func (c *client) sendRooms(filter *GetRoomsPayload) {
+	ctx, cancel := context.WithTimeout(c.ctx, c.cfg.WriteTimeout)
+	defer cancel()
+	rooms, err := c.hub.index.list(ctx, filter)
+	if err != nil {
+		slog.Error("sendRooms: failed to list rooms", "error", err, "client", c.ID)
+		c.trySend(sendMessage(msgError, "Cannot list rooms. Please try again later."))
+		return
+	}
+	c.trySend(sendKeyVal(msgGetRooms, "rooms", rooms))
+}
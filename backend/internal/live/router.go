@@ -4,30 +4,73 @@ import (
 	"gonext/internal/config"
 	"gonext/internal/game"
 	"gonext/internal/mdw"
+	"gonext/internal/repo"
 	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/coder/websocket"
 	"github.com/go-chi/chi/v5"
 )
 
-func Router(registry *game.Registry, cfg *config.WS) chi.Router {
-	hub := newhub(registry, cfg)
+func Router(registry *game.Registry, games repo.GameRepo, ratings repo.RatingRepo, kv repo.KVStore, bus repo.Bus, cfg *config.WS) chi.Router {
+	rec := &recorder{games: games, ratings: ratings, snaps: newSnapshotter(kv, cfg)}
+	cl := newCluster(kv, bus, cfg)
+	index := newRoomIndex(kv, cfg)
+	invites, err := newInviteManager(cfg)
+	if err != nil {
+		panic(err)
+	}
+	sess := newSessions(kv, cfg)
+	hub := newhub(registry, rec, cl, sess, index, invites, &bans{kv: kv, cfg: cfg}, cfg)
+	go rec.snaps.run()
+	go sess.w.run()
+	go index.w.run()
+	go cl.run()
 	go hub.run()
 
 	r := chi.NewRouter()
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		conn, err := websocket.Accept(w, r, nil)
-		if err != nil {
-			slog.Error("Failed to accept WebSocket connection.", "error", err)
-			return
-		}
 		user := mdw.GetUser(r.Context())
 		if user == nil {
 			slog.Error("No user in context for WebSocket connection")
 			return
 		}
-		hub.register <- newClient(hub, conn, user, hub.cfg)
+		ratings := rec.loadRatings(r.Context(), user)
+		session, resume := hub.startSession(r, user.Username)
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			slog.Error("Failed to accept WebSocket connection.", "error", err)
+			return
+		}
+		client := newClient(hub, conn, user, hub.cfg)
+		client.ratings = ratings
+		client.session = session
+		client.resume = resume
+		hub.register <- client
 	})
 	return r
 }
+
+// startSession picks up the session in the request's resume token, or
+// starts a new one. With seq, the client comes back to its room without
+// missing a message.
+func (h *hub) startSession(r *http.Request, user string) (string, *resumePoint) {
+	query := r.URL.Query()
+	if token := query.Get("resume"); token != "" {
+		sess, err := h.sessions.resume(r.Context(), token, user)
+		if err == nil {
+			since, err := strconv.ParseInt(query.Get("seq"), 10, 64)
+			if err != nil {
+				since = noResume
+			}
+			return token, &resumePoint{room: sess.Room, key: sess.Key, owner: h.findOwner(sess.Room), since: since}
+		}
+		slog.Debug("Cannot resume session.", "error", err, "user", user)
+	}
+	token, err := h.sessions.open(r.Context(), user)
+	if err != nil {
+		slog.Error("Failed to open session.", "error", err, "user", user)
+	}
+	return token, nil
+}
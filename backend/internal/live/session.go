@@ -0,0 +1,87 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gonext/internal/config"
+	"gonext/internal/repo"
+
+	"github.com/google/uuid"
+)
+
+// session lets a client that lost its connection come back to the room it
+// was in. It is kept in Redis under the resume token handed out on connect,
+// so the client can come back to any instance.
+type session struct {
+	User string `json:"user"`
+	Room string `json:"room"`
+	Key  string `json:"key,omitempty"` // of the room when the client was let in
+}
+
+// sessions writes in the order the hub moves its clients, through w.
+type sessions struct {
+	kv  repo.KVStore
+	w   *kvWriter
+	cfg *config.WS
+}
+
+func newSessions(kv repo.KVStore, cfg *config.WS) *sessions {
+	return &sessions{kv: kv, w: newKVWriter(kv, "sessions", cfg.SessionKey, "", cfg.SessionTTL), cfg: cfg}
+}
+
+// open starts a session for user in the lobby and returns its token.
+func (s *sessions) open(ctx context.Context, user string) (string, error) {
+	token := uuid.NewString()
+	if err := s.put(ctx, token, &session{User: user, Room: lobbyName}, s.cfg.SessionTTL); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// resume finds the session of token, which must belong to user.
+func (s *sessions) resume(ctx context.Context, token, user string) (*session, error) {
+	data, err := s.kv.Get(ctx, fmt.Sprintf(s.cfg.SessionKey, token))
+	if err != nil {
+		return nil, errors.New("session expired")
+	}
+	sess := &session{}
+	if err := json.Unmarshal([]byte(data), sess); err != nil {
+		return nil, fmt.Errorf("sessions: failed to decode session: %w", err)
+	}
+	if sess.User != user {
+		return nil, errors.New("session belongs to someone else")
+	}
+	return sess, nil
+}
+
+// move records the room a connected client is now in.
+func (s *sessions) move(token, user, room, key string) {
+	s.write(token, &session{User: user, Room: room, Key: key}, s.cfg.SessionTTL)
+}
+
+// close leaves the session open for ResumeGrace after its client went away.
+func (s *sessions) close(token, user, room, key string) {
+	s.write(token, &session{User: user, Room: room, Key: key}, s.cfg.ResumeGrace)
+}
+
+func (s *sessions) write(token string, sess *session, ttl time.Duration) {
+	if token == "" {
+		return // the client never got one
+	}
+	s.w.putFor(token, sess, ttl)
+}
+
+func (s *sessions) put(ctx context.Context, token string, sess *session, ttl time.Duration) error {
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return fmt.Errorf("sessions: failed to encode session: %w", err)
+	}
+	if err := s.kv.Set(ctx, fmt.Sprintf(s.cfg.SessionKey, token), string(data), ttl); err != nil {
+		return fmt.Errorf("sessions: failed to save session: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package live
+
+import (
+	"context"
+	"log/slog"
+
+	"gonext/internal/config"
+	"gonext/internal/game"
+	"gonext/internal/repo"
+)
+
+// roomSnapshot is a room's running game as kept in Redis.
+type roomSnapshot struct {
+	Room   string         `json:"room"`
+	Bot    string         `json:"bot,omitempty"` // difficulty of the room's bot
+	Series *series        `json:"series,omitempty"`
+	Info   *roomInfo      `json:"info,omitempty"` // keeps a password room from coming back open
+	Game   *game.Snapshot `json:"game"`
+}
+
+// snapshotter keeps running games in Redis so they survive a restart.
+type snapshotter struct {
+	w *kvWriter
+}
+
+func newSnapshotter(kv repo.KVStore, cfg *config.WS) *snapshotter {
+	return &snapshotter{w: newKVWriter(kv, "snapshotter", cfg.SnapshotKey, cfg.SnapshotIndex, cfg.SnapshotTTL)}
+}
+
+func (s *snapshotter) save(snap *roomSnapshot) {
+	s.w.put(snap.Room, snap)
+}
+
+func (s *snapshotter) remove(room string) {
+	s.w.remove(room)
+}
+
+func (s *snapshotter) run() {
+	s.w.run()
+}
+
+// load reads back the snapshots written within SnapshotTTL. Ones that cannot
+// be read are dropped.
+func (s *snapshotter) load(ctx context.Context) []*roomSnapshot {
+	rooms, err := s.w.members(ctx)
+	if err != nil {
+		slog.Error("snapshotter: failed to list rooms", "error", err)
+		return nil
+	}
+	snaps := make([]*roomSnapshot, 0, len(rooms))
+	for _, room := range rooms {
+		if snap := s.get(ctx, room); snap != nil {
+			snaps = append(snaps, snap)
+		}
+	}
+	return snaps
+}
+
+// get reads back one room's snapshot, if it has a readable one.
+func (s *snapshotter) get(ctx context.Context, room string) *roomSnapshot {
+	snap := &roomSnapshot{}
+	if err := s.w.read(ctx, room, snap); err != nil {
+		return nil
+	}
+	return snap
+}
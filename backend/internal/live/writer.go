@@ -0,0 +1,138 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gonext/internal/repo"
+)
+
+// kvWriter keeps JSON values in Redis under a key per member, along with an
+// index of the members if it has one. Writes happen in the background, one
+// at a time; when they fall behind, only a member's latest value is written.
+type kvWriter struct {
+	kv      repo.KVStore
+	name    string // for logs
+	key     string // format of a member's key
+	index   string // "" for none
+	ttl     time.Duration
+	mu      sync.Mutex
+	pending map[string]kvWrite // by member
+	wake    chan struct{}
+}
+
+type kvWrite struct {
+	data []byte // nil to delete
+	ttl  time.Duration
+}
+
+func newKVWriter(kv repo.KVStore, name, key, index string, ttl time.Duration) *kvWriter {
+	return &kvWriter{
+		kv:      kv,
+		name:    name,
+		key:     key,
+		index:   index,
+		ttl:     ttl,
+		pending: make(map[string]kvWrite),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// put encodes value right away, so the caller may change it afterwards.
+func (w *kvWriter) put(member string, value any) {
+	w.putFor(member, value, w.ttl)
+}
+
+// putFor is put with a ttl of its own.
+func (w *kvWriter) putFor(member string, value any, ttl time.Duration) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		slog.Error(w.name+": failed to encode", "error", err, "member", member)
+		return
+	}
+	w.queue(member, kvWrite{data: data, ttl: ttl})
+}
+
+func (w *kvWriter) remove(member string) {
+	w.queue(member, kvWrite{})
+}
+
+func (w *kvWriter) queue(member string, write kvWrite) {
+	w.mu.Lock()
+	w.pending[member] = write
+	w.mu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (w *kvWriter) run() {
+	for range w.wake {
+		w.mu.Lock()
+		pending := w.pending
+		w.pending = make(map[string]kvWrite)
+		w.mu.Unlock()
+		for member, write := range pending {
+			w.write(member, write)
+		}
+	}
+}
+
+func (w *kvWriter) write(member string, write kvWrite) {
+	ctx, cancel := context.WithTimeout(context.Background(), saveTimeout)
+	defer cancel()
+
+	key := fmt.Sprintf(w.key, member)
+	var err error
+	switch {
+	case write.data == nil && w.index == "":
+		err = w.kv.Del(ctx, key)
+	case write.data == nil:
+		err = errors.Join(
+			w.kv.ListDel(ctx, w.index, member),
+			w.kv.Del(ctx, key),
+		)
+	case w.index == "":
+		err = w.kv.Set(ctx, key, string(write.data), write.ttl)
+	default:
+		err = errors.Join(
+			w.kv.Set(ctx, key, string(write.data), write.ttl),
+			w.kv.ListAdd(ctx, w.index, member, write.ttl),
+		)
+	}
+	if err != nil {
+		slog.Error(w.name+": failed to write", "error", err, "member", member)
+	}
+}
+
+// members lists what was written within the ttl.
+func (w *kvWriter) members(ctx context.Context) ([]string, error) {
+	if err := w.kv.ListTrim(ctx, w.index, w.ttl); err != nil {
+		slog.Error(w.name+": failed to trim index", "error", err)
+	}
+	members, err := w.kv.ListGet(ctx, w.index)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to list: %w", w.name, err)
+	}
+	return members, nil
+}
+
+// read decodes a member's value into v. A member whose value is gone or
+// cannot be decoded is dropped.
+func (w *kvWriter) read(ctx context.Context, member string, v any) error {
+	data, err := w.kv.Get(ctx, fmt.Sprintf(w.key, member))
+	if err == nil {
+		if err = json.Unmarshal([]byte(data), v); err == nil {
+			return nil
+		}
+	}
+	slog.Warn(w.name+": dropping", "error", err, "member", member)
+	w.write(member, kvWrite{})
+	return fmt.Errorf("%s: failed to read: %w", w.name, err)
+}
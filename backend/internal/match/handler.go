@@ -0,0 +1,150 @@
+package match
+
+import (
+	"errors"
+	"gonext/internal/game"
+	"gonext/internal/mdw"
+	"gonext/internal/model"
+	"gonext/internal/repo"
+	"gonext/pkg/util/httputil"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type handler struct {
+	registry *game.Registry
+	games    repo.GameRepo
+	ratings  repo.RatingRepo
+}
+
+func newHandler(registry *game.Registry, games repo.GameRepo, ratings repo.RatingRepo) *handler {
+	return &handler{registry: registry, games: games, ratings: ratings}
+}
+
+// listGamesHandler lists the finished games of ?user=, or of the caller.
+func (h *handler) listGamesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := r.URL.Query().Get("user")
+		if username == "" {
+			username = mdw.GetUser(r.Context()).Username
+		}
+		limit, offset, ok := pageParams(r)
+		if !ok {
+			httputil.RespondErr(w, http.StatusBadRequest, "Invalid limit or offset", nil)
+			return
+		}
+
+		games, err := h.games.ListGamesByPlayer(r.Context(), username, limit, offset)
+		if err != nil {
+			httputil.RespondErr(w, http.StatusInternalServerError, "Failed to list games", err)
+			return
+		}
+		res := make([]*gameRes, 0, len(games))
+		for _, game := range games {
+			res = append(res, toGameRes(game))
+		}
+		httputil.RespondJSON(w, http.StatusOK, res)
+	}
+}
+
+func (h *handler) getGameHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		game, ok := h.readGame(w, r)
+		if !ok {
+			return
+		}
+		httputil.RespondJSON(w, http.StatusOK, toGameRes(game))
+	}
+}
+
+// replayGameHandler returns the game's state before the first move and
+// after every move.
+func (h *handler) replayGameHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stored, ok := h.readGame(w, r)
+		if !ok {
+			return
+		}
+		rec, err := ToRecord(stored)
+		if err != nil {
+			httputil.RespondErr(w, http.StatusInternalServerError, "Failed to decode game", err)
+			return
+		}
+		states, err := h.registry.Replay(rec)
+		if err != nil {
+			httputil.RespondErr(w, http.StatusInternalServerError, "Failed to replay game", err)
+			return
+		}
+		httputil.RespondJSON(w, http.StatusOK, states)
+	}
+}
+
+// readGame reads the game {id} if it is over or the caller played in it.
+func (h *handler) readGame(w http.ResponseWriter, r *http.Request) (*model.GameRecord, bool) {
+	id := chi.URLParam(r, "id")
+	if err := uuid.Validate(id); err != nil {
+		httputil.RespondErr(w, http.StatusNotFound, "Game not found", nil)
+		return nil, false
+	}
+
+	game, err := h.games.ReadGame(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			httputil.RespondErr(w, http.StatusNotFound, "Game not found", nil)
+			return nil, false
+		}
+		httputil.RespondErr(w, http.StatusInternalServerError, "Failed to get game", err)
+		return nil, false
+	}
+	if game.EndedAt.IsZero() && !slices.Contains(game.Players, mdw.GetUser(r.Context()).Username) {
+		httputil.RespondErr(w, http.StatusNotFound, "Game not found", nil)
+		return nil, false
+	}
+	return game, true
+}
+
+// listRatingsHandler lists the ratings of {username}, or of the caller.
+func (h *handler) listRatingsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := chi.URLParam(r, "username")
+		if username == "" {
+			username = mdw.GetUser(r.Context()).Username
+		}
+
+		ratings, err := h.ratings.ReadRatings(r.Context(), username)
+		if err != nil {
+			httputil.RespondErr(w, http.StatusInternalServerError, "Failed to get ratings", err)
+			return
+		}
+		res := make([]*ratingRes, 0, len(ratings))
+		for _, rating := range ratings {
+			res = append(res, toRatingRes(rating))
+		}
+		httputil.RespondJSON(w, http.StatusOK, res)
+	}
+}
+
+func pageParams(r *http.Request) (int, int, bool) {
+	limit, offset := defaultPageSize, 0
+	var err error
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > maxPageSize {
+			return 0, 0, false
+		}
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, false
+		}
+	}
+	return limit, offset, true
+}
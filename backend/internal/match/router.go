@@ -0,0 +1,27 @@
+package match
+
+import (
+	"gonext/internal/game"
+	"gonext/internal/repo"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func Router(registry *game.Registry, games repo.GameRepo, ratings repo.RatingRepo) chi.Router {
+	h := newHandler(registry, games, ratings)
+
+	r := chi.NewRouter()
+	r.Get("/", h.listGamesHandler())
+	r.Get("/{id}", h.getGameHandler())
+	r.Get("/{id}/replay", h.replayGameHandler())
+	return r
+}
+
+func RatingRouter(ratings repo.RatingRepo) chi.Router {
+	h := newHandler(nil, nil, ratings)
+
+	r := chi.NewRouter()
+	r.Get("/", h.listRatingsHandler())
+	r.Get("/{username}", h.listRatingsHandler())
+	return r
+}
@@ -0,0 +1,115 @@
+package match
+
+import (
+	"encoding/json"
+	"gonext/internal/game"
+	"gonext/internal/model"
+	"gonext/internal/rating"
+	"slices"
+	"time"
+)
+
+type gameRes struct {
+	ID        string          `json:"id"`
+	GameName  string          `json:"gameName"`
+	Players   []string        `json:"players"`
+	Winner    string          `json:"winner"`
+	Winners   []string        `json:"winners,omitempty"`
+	Reason    string          `json:"reason,omitempty"`
+	Options   json.RawMessage `json:"options,omitempty"`
+	StartedAt time.Time       `json:"startedAt"`
+	EndedAt   time.Time       `json:"endedAt"`
+	Moves     []moveRes       `json:"moves,omitempty"`
+}
+
+type moveRes struct {
+	Ply      int             `json:"ply"`
+	Player   string          `json:"player"`
+	Move     json.RawMessage `json:"move"`
+	PlayedAt time.Time       `json:"playedAt"`
+}
+
+type ratingRes struct {
+	Username    string    `json:"username"`
+	GameName    string    `json:"gameName"`
+	Rating      int       `json:"rating"`
+	Provisional bool      `json:"provisional"`
+	Games       int       `json:"games"`
+	Wins        int       `json:"wins"`
+	Losses      int       `json:"losses"`
+	Draws       int       `json:"draws"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// ToRecord turns a stored game back into the record the game package made.
+func ToRecord(g *model.GameRecord) (*game.Record, error) {
+	rec := &game.Record{
+		ID:        g.ID,
+		GameName:  g.GameName,
+		Players:   g.Players,
+		Winner:    g.Winner,
+		Winners:   g.Winners,
+		Reason:    g.Reason,
+		StartedAt: g.StartedAt,
+		EndedAt:   g.EndedAt,
+		Moves:     make([]game.PlayedMove, 0, len(g.Moves)),
+	}
+	if rec.Winners == nil && rec.Winner != "" {
+		// stored before games could have several winners
+		rec.Winners = []string{rec.Winner}
+	}
+	if len(g.Options) > 0 {
+		if err := json.Unmarshal(g.Options, &rec.Options); err != nil {
+			return nil, err
+		}
+	}
+	for _, mv := range g.Moves {
+		played := game.PlayedMove{
+			Seat:   slices.Index(g.Players, mv.Player),
+			Player: mv.Player,
+			At:     mv.PlayedAt,
+		}
+		if err := json.Unmarshal(mv.Move, &played.Move); err != nil {
+			return nil, err
+		}
+		rec.Moves = append(rec.Moves, played)
+	}
+	return rec, nil
+}
+
+func toRatingRes(r *model.Rating) *ratingRes {
+	return &ratingRes{
+		Username:    r.Username,
+		GameName:    r.GameName,
+		Rating:      r.Rating,
+		Provisional: rating.Provisional(r),
+		Games:       r.Games,
+		Wins:        r.Wins,
+		Losses:      r.Losses,
+		Draws:       r.Draws,
+		UpdatedAt:   r.UpdatedAt,
+	}
+}
+
+func toGameRes(game *model.GameRecord) *gameRes {
+	res := &gameRes{
+		ID:        game.ID,
+		GameName:  game.GameName,
+		Players:   game.Players,
+		Winner:    game.Winner,
+		Winners:   game.Winners,
+		Reason:    game.Reason,
+		Options:   game.Options,
+		StartedAt: game.StartedAt,
+		EndedAt:   game.EndedAt,
+	}
+	for _, mv := range game.Moves {
+		res.Moves = append(res.Moves, moveRes{
+			Ply:      mv.Ply,
+			Player:   mv.Player,
+			Move:     mv.Move,
+			PlayedAt: mv.PlayedAt,
+		})
+	}
+	return res
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type GameRecord struct {
+	ID        string          `db:"id"`
+	GameName  string          `db:"game_name"`
+	Players   []string        `db:"players"`
+	Winner    string          `db:"winner"`
+	Winners   []string        `db:"winners"`
+	Reason    string          `db:"reason"`
+	Options   json.RawMessage `db:"options"`
+	StartedAt time.Time       `db:"started_at"`
+	EndedAt   time.Time       `db:"ended_at"`
+	Moves     []GameMoveRecord
+}
+
+type GameMoveRecord struct {
+	Ply      int             `db:"ply"`
+	Player   string          `db:"player"`
+	Move     json.RawMessage `db:"move"`
+	PlayedAt time.Time       `db:"played_at"`
+}
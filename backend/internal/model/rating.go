@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+type Rating struct {
+	UserID    string    `db:"user_id"`
+	Username  string    `db:"username"`
+	GameName  string    `db:"game_name"`
+	Rating    int       `db:"rating"`
+	Games     int       `db:"games"`
+	Wins      int       `db:"wins"`
+	Losses    int       `db:"losses"`
+	Draws     int       `db:"draws"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
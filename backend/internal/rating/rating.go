@@ -0,0 +1,63 @@
+package rating
+
+import (
+	"math"
+
+	"gonext/internal/model"
+)
+
+const (
+	Initial          = 1500
+	ProvisionalGames = 10
+
+	provisionalK = 40
+	establishedK = 20
+)
+
+func Provisional(r *model.Rating) bool {
+	return r.Games < ProvisionalGames
+}
+
+// Score is player's result in a two player game: 1 for a win, 0.5 for a
+// draw and 0 for a loss.
+func Score(winner, player string) float64 {
+	switch winner {
+	case "":
+		return 0.5
+	case player:
+		return 1
+	}
+	return 0
+}
+
+// Apply updates both Elo ratings and tallies after a game in which a scored
+// scoreA against b.
+func Apply(a, b *model.Rating, scoreA float64) {
+	expectedA := 1 / (1 + math.Pow(10, float64(b.Rating-a.Rating)/400))
+	deltaA := kFactor(a) * (scoreA - expectedA)
+	deltaB := kFactor(b) * (expectedA - scoreA)
+
+	a.Rating += int(math.Round(deltaA))
+	b.Rating += int(math.Round(deltaB))
+	tally(a, scoreA)
+	tally(b, 1-scoreA)
+}
+
+func kFactor(r *model.Rating) float64 {
+	if Provisional(r) {
+		return provisionalK
+	}
+	return establishedK
+}
+
+func tally(r *model.Rating, score float64) {
+	r.Games++
+	switch score {
+	case 1:
+		r.Wins++
+	case 0:
+		r.Losses++
+	default:
+		r.Draws++
+	}
+}
@@ -0,0 +1,52 @@
+package rating
+
+import (
+	"testing"
+
+	"gonext/internal/model"
+)
+
+func TestApplyEven(t *testing.T) {
+	a := &model.Rating{Rating: Initial, Games: ProvisionalGames}
+	b := &model.Rating{Rating: Initial, Games: ProvisionalGames}
+	Apply(a, b, Score("a", "a"))
+	if a.Rating != Initial+10 || b.Rating != Initial-10 {
+		t.Errorf("even game won by a left %d and %d", a.Rating, b.Rating)
+	}
+	if a.Games != ProvisionalGames+1 || a.Wins != 1 || b.Losses != 1 {
+		t.Errorf("tallies %+v and %+v", a, b)
+	}
+}
+
+func TestApplyProvisional(t *testing.T) {
+	a := &model.Rating{Rating: Initial}
+	b := &model.Rating{Rating: Initial, Games: ProvisionalGames}
+	Apply(a, b, 0)
+	if a.Rating != Initial-20 || b.Rating != Initial+10 {
+		t.Errorf("newcomer losing moved %d and %d, want twice as far for the newcomer", a.Rating, b.Rating)
+	}
+}
+
+func TestApplyDraw(t *testing.T) {
+	a := &model.Rating{Rating: 1700, Games: ProvisionalGames}
+	b := &model.Rating{Rating: 1500, Games: ProvisionalGames}
+	Apply(a, b, Score("", "a"))
+	// a was expected to score about 0.76.
+	if a.Rating != 1695 || b.Rating != 1505 {
+		t.Errorf("draw against the weaker player left %d and %d", a.Rating, b.Rating)
+	}
+	if a.Draws != 1 || b.Draws != 1 {
+		t.Errorf("draws tallied %d and %d", a.Draws, b.Draws)
+	}
+}
+
+func TestScore(t *testing.T) {
+	for _, tc := range []struct {
+		winner string
+		want   float64
+	}{{"a", 1}, {"b", 0}, {"", 0.5}} {
+		if got := Score(tc.winner, "a"); got != tc.want {
+			t.Errorf("Score(%q, a) = %v, want %v", tc.winner, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const busBuffer = 256
+
+// Bus carries messages between server instances.
+type Bus interface {
+	Publish(ctx context.Context, channel string, msg []byte) error
+	// Subscribe delivers the messages published to channel until ctx is done.
+	Subscribe(ctx context.Context, channel string) <-chan []byte
+}
+
+func newBus(rdb *redis.Client) Bus {
+	return &rdsBus{rdb: rdb}
+}
+
+type rdsBus struct {
+	rdb *redis.Client
+}
+
+func (b *rdsBus) Publish(ctx context.Context, channel string, msg []byte) error {
+	return b.rdb.Publish(ctx, channel, msg).Err()
+}
+
+func (b *rdsBus) Subscribe(ctx context.Context, channel string) <-chan []byte {
+	sub := b.rdb.Subscribe(ctx, channel)
+	out := make(chan []byte, busBuffer)
+	go func() {
+		defer close(out)
+		defer sub.Close()
+		msgs := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				out <- []byte(msg.Payload)
+			}
+		}
+	}()
+	return out
+}
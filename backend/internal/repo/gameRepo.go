@@ -0,0 +1,169 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gonext/internal/model"
+
+	"github.com/lib/pq"
+)
+
+type GameRepo interface {
+	CreateGame(ctx context.Context, game *model.GameRecord) error
+	ReadGame(ctx context.Context, id string) (*model.GameRecord, error)
+	ListGamesByPlayer(ctx context.Context, username string, limit, offset int) ([]*model.GameRecord, error)
+}
+
+func newGameRepo(db *sql.DB) GameRepo {
+	return &pgGameRepo{db: db}
+}
+
+type pgGameRepo struct {
+	db *sql.DB
+}
+
+func (r *pgGameRepo) CreateGame(ctx context.Context, game *model.GameRecord) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("repo: failed to create game: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO games (id, game_name, players, winner, winners, reason, options, started_at, ended_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, ''), $7, $8, $9)
+	`,
+		game.ID,
+		game.GameName,
+		pq.Array(game.Players),
+		game.Winner,
+		pq.Array(game.Winners),
+		game.Reason,
+		nullJSON(game.Options),
+		game.StartedAt,
+		game.EndedAt,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			err = fmt.Errorf("%w: %w", ErrAlreadyExists, err)
+		}
+		return fmt.Errorf("repo: failed to create game: %w", err)
+	}
+
+	for _, mv := range game.Moves {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO game_moves (game_id, ply, player, move, played_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`, game.ID, mv.Ply, mv.Player, string(mv.Move), mv.PlayedAt)
+		if err != nil {
+			return fmt.Errorf("repo: failed to create game move %d: %w", mv.Ply, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repo: failed to create game: %w", err)
+	}
+	return nil
+}
+
+func (r *pgGameRepo) ReadGame(ctx context.Context, id string) (*model.GameRecord, error) {
+	query := `
+		SELECT id, game_name, players, winner, winners, reason, options, started_at, ended_at
+		FROM games
+		WHERE id = $1
+	`
+	game, err := scanGame(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("repo: failed to get game by ID=%v: %w", id, err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT ply, player, move, played_at
+		FROM game_moves
+		WHERE game_id = $1
+		ORDER BY ply
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("repo: failed to get moves for game ID=%v: %w", id, err)
+	}
+	defer rows.Close()
+
+	game.Moves = []model.GameMoveRecord{}
+	for rows.Next() {
+		var mv model.GameMoveRecord
+		var move []byte
+		if err := rows.Scan(&mv.Ply, &mv.Player, &move, &mv.PlayedAt); err != nil {
+			return nil, fmt.Errorf("repo: failed to scan move: %w", err)
+		}
+		mv.Move = move
+		game.Moves = append(game.Moves, mv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repo: failed to get moves for game ID=%v: %w", id, err)
+	}
+	return game, nil
+}
+
+func (r *pgGameRepo) ListGamesByPlayer(ctx context.Context, username string, limit, offset int) ([]*model.GameRecord, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, game_name, players, winner, winners, reason, options, started_at, ended_at
+		FROM games
+		WHERE $1 = ANY(players)
+		ORDER BY ended_at DESC
+		LIMIT $2 OFFSET $3
+	`, username, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("repo: failed to list games for %v: %w", username, err)
+	}
+	defer rows.Close()
+
+	games := []*model.GameRecord{}
+	for rows.Next() {
+		game, err := scanGame(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repo: failed to scan game: %w", err)
+		}
+		games = append(games, game)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repo: failed to list games for %v: %w", username, err)
+	}
+	return games, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanGame(row scanner) (*model.GameRecord, error) {
+	var game model.GameRecord
+	var winner, reason sql.NullString
+	var options []byte
+	err := row.Scan(
+		&game.ID,
+		&game.GameName,
+		pq.Array(&game.Players),
+		&winner,
+		pq.Array(&game.Winners),
+		&reason,
+		&options,
+		&game.StartedAt,
+		&game.EndedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	game.Winner = winner.String
+	game.Reason = reason.String
+	game.Options = options
+	return &game, nil
+}
+
+// nullJSON passes raw JSON as text; lib/pq would send a []byte as bytea.
+func nullJSON(raw []byte) sql.NullString {
+	return sql.NullString{String: string(raw), Valid: len(raw) > 0}
+}
@@ -12,6 +12,11 @@ type KVStore interface {
 	Set(ctx context.Context, key, value string, ttl time.Duration) error
 	Del(ctx context.Context, key string) error
 	Get(ctx context.Context, key string) (string, error)
+	// Claim sets key to value unless it holds something else, renewing the
+	// ttl if it already holds value, and returns what key holds. It is atomic.
+	Claim(ctx context.Context, key, value string, ttl time.Duration) (string, error)
+	// DelIf deletes key only if it holds value. It is atomic.
+	DelIf(ctx context.Context, key, value string) error
 
 	ListAdd(ctx context.Context, key, val string, ttl time.Duration) error
 	ListDel(ctx context.Context, key, val string) error
@@ -38,6 +43,32 @@ func (r *rdsStore) Del(ctx context.Context, key string) error {
 	return r.rdb.Del(ctx, key).Err()
 }
 
+var claimScript = redis.NewScript(`
+local held = redis.call("GET", KEYS[1])
+if not held then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return ARGV[1]
+end
+if held == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return held
+`)
+
+var delIfScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func (r *rdsStore) Claim(ctx context.Context, key, val string, ttl time.Duration) (string, error) {
+	return claimScript.Run(ctx, r.rdb, []string{key}, val, ttl.Milliseconds()).Text()
+}
+func (r *rdsStore) DelIf(ctx context.Context, key, val string) error {
+	return delIfScript.Run(ctx, r.rdb, []string{key}, val).Err()
+}
+
 func (r *rdsStore) ListAdd(ctx context.Context, key, val string, ttl time.Duration) error {
 	if err := r.rdb.ZAdd(ctx, key, redis.Z{
 		Score:  float64(time.Now().Unix()),
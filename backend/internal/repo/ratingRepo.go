@@ -0,0 +1,152 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gonext/internal/model"
+
+	"github.com/lib/pq"
+)
+
+type RatingRepo interface {
+	ReadRatings(ctx context.Context, username string) ([]*model.Rating, error)
+	// UpdateRatings locks the ratings of usernames for gameName and saves
+	// whatever update does to them. They are handed over in the order given.
+	// ErrNotFound means one of the players has no registered account.
+	UpdateRatings(ctx context.Context, gameName string, usernames []string,
+		update func(ratings []*model.Rating)) ([]*model.Rating, error)
+}
+
+func newRatingRepo(db *sql.DB) RatingRepo {
+	return &pgRatingRepo{db: db}
+}
+
+type pgRatingRepo struct {
+	db *sql.DB
+}
+
+func (r *pgRatingRepo) ReadRatings(ctx context.Context, username string) ([]*model.Rating, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT r.user_id, u.username, r.game_name, r.rating, r.games, r.wins, r.losses, r.draws, r.updated_at
+		FROM ratings r
+		JOIN users u ON u.id = r.user_id
+		WHERE u.username = $1
+		ORDER BY r.game_name
+	`, username)
+	if err != nil {
+		return nil, fmt.Errorf("repo: failed to get ratings for %v: %w", username, err)
+	}
+	defer rows.Close()
+
+	ratings := []*model.Rating{}
+	for rows.Next() {
+		rating, err := scanRating(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repo: failed to scan rating: %w", err)
+		}
+		ratings = append(ratings, rating)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repo: failed to get ratings for %v: %w", username, err)
+	}
+	return ratings, nil
+}
+
+func (r *pgRatingRepo) UpdateRatings(ctx context.Context, gameName string, usernames []string,
+	update func(ratings []*model.Rating)) ([]*model.Rating, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("repo: failed to update ratings: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO ratings (user_id, game_name)
+		SELECT id, $2 FROM users
+		WHERE username = ANY($1) AND account_type <> 'guest'
+		ON CONFLICT DO NOTHING
+	`, pq.Array(usernames), gameName)
+	if err != nil {
+		return nil, fmt.Errorf("repo: failed to update ratings: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT r.user_id, u.username, r.game_name, r.rating, r.games, r.wins, r.losses, r.draws, r.updated_at
+		FROM ratings r
+		JOIN users u ON u.id = r.user_id
+		WHERE u.username = ANY($1) AND u.account_type <> 'guest' AND r.game_name = $2
+		FOR UPDATE OF r
+	`, pq.Array(usernames), gameName)
+	if err != nil {
+		return nil, fmt.Errorf("repo: failed to update ratings: %w", err)
+	}
+	byName := make(map[string]*model.Rating, len(usernames))
+	for rows.Next() {
+		rating, err := scanRating(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("repo: failed to scan rating: %w", err)
+		}
+		byName[rating.Username] = rating
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repo: failed to update ratings: %w", err)
+	}
+
+	ratings := make([]*model.Rating, 0, len(usernames))
+	for _, name := range usernames {
+		rating, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("repo: no rating for %v: %w", name, ErrNotFound)
+		}
+		ratings = append(ratings, rating)
+	}
+
+	update(ratings)
+
+	for _, rating := range ratings {
+		err := tx.QueryRowContext(ctx, `
+			UPDATE ratings
+			SET rating = $3, games = $4, wins = $5, losses = $6, draws = $7
+			WHERE user_id = $1 AND game_name = $2
+			RETURNING updated_at
+		`,
+			rating.UserID,
+			rating.GameName,
+			rating.Rating,
+			rating.Games,
+			rating.Wins,
+			rating.Losses,
+			rating.Draws,
+		).Scan(&rating.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("repo: failed to update rating for %v: %w", rating.Username, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("repo: failed to update ratings: %w", err)
+	}
+	return ratings, nil
+}
+
+func scanRating(row scanner) (*model.Rating, error) {
+	var rating model.Rating
+	err := row.Scan(
+		&rating.UserID,
+		&rating.Username,
+		&rating.GameName,
+		&rating.Rating,
+		&rating.Games,
+		&rating.Wins,
+		&rating.Losses,
+		&rating.Draws,
+		&rating.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rating, nil
+}
@@ -8,12 +8,18 @@ import (
 
 type Store struct {
 	User    UserRepo
+	Game    GameRepo
+	Rating  RatingRepo
 	KVStore KVStore
+	Bus     Bus
 }
 
 func NewStore(db *sql.DB, rds *redis.Client) *Store {
 	return &Store{
 		User:    newUserRepo(db),
+		Game:    newGameRepo(db),
+		Rating:  newRatingRepo(db),
 		KVStore: newKVStore(rds),
+		Bus:     newBus(rds),
 	}
 }
@@ -1,3 +1,117 @@
+// Package webrtc routes the signaling of a room's video call. Calls are a
+// mesh: each member connects to every other, so every offer, answer and ICE
+// candidate is meant for exactly one peer.
 package webrtc
 
-// Add WebRTC signaling logic here
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+)
+
+// Signal types.
+const (
+	Join   = "join"   // the sender enters the call; members offer it a connection
+	Leave  = "leave"  // the sender left the call
+	Offer  = "offer"  // to one peer
+	Answer = "answer" // to one peer
+	ICE    = "ice"    // to one peer
+	Roster = "roster" // who is in the call, from the server
+)
+
+var (
+	ErrBadSignal   = errors.New("invalid signal")
+	ErrNoTarget    = errors.New("signal needs a target")
+	ErrNotInCall   = errors.New("join the call first")
+	ErrUnknownPeer = errors.New("target is not in the call")
+	ErrCallFull    = errors.New("the call is full")
+)
+
+type Signal struct {
+	Type      string          `json:"type"`
+	Target    string          `json:"target,omitempty"`
+	Offer     json.RawMessage `json:"offer,omitempty"`
+	Answer    json.RawMessage `json:"answer,omitempty"`
+	Candidate json.RawMessage `json:"candidate,omitempty"`
+	Members   []string        `json:"members,omitempty"` // on roster
+}
+
+// Mesh is the roster of one room's call.
+type Mesh struct {
+	mu      sync.Mutex
+	max     int
+	members []string // in the order they joined
+}
+
+// NewMesh makes an empty call for up to max members, or any number if max
+// is 0.
+func NewMesh(max int) *Mesh {
+	return &Mesh{max: max}
+}
+
+// Members lists who is in the call.
+func (m *Mesh) Members() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.members)
+}
+
+// Route checks a signal from sender and changes the roster for a join or a
+// leave. It returns the members the signal goes to, and whether the roster
+// changed.
+func (m *Mesh) Route(sender string, s *Signal) ([]string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	in := slices.Contains(m.members, sender)
+	switch s.Type {
+	case Join:
+		if in {
+			return m.othersLocked(sender), false, nil
+		}
+		if m.max > 0 && len(m.members) >= m.max {
+			return nil, false, ErrCallFull
+		}
+		m.members = append(m.members, sender)
+		return m.othersLocked(sender), true, nil
+
+	case Leave:
+		to, changed := m.dropLocked(sender)
+		return to, changed, nil
+
+	case Offer, Answer, ICE:
+		switch {
+		case s.Target == "":
+			return nil, false, ErrNoTarget
+		case s.Type == Offer && s.Offer == nil, s.Type == Answer && s.Answer == nil, s.Type == ICE && s.Candidate == nil:
+			return nil, false, ErrBadSignal
+		case !in:
+			return nil, false, ErrNotInCall
+		case s.Target == sender || !slices.Contains(m.members, s.Target):
+			return nil, false, ErrUnknownPeer
+		}
+		return []string{s.Target}, false, nil
+	}
+	return nil, false, ErrBadSignal
+}
+
+// Drop takes out a member who left the room, returning who is still in the
+// call, if they were in it.
+func (m *Mesh) Drop(user string) ([]string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.dropLocked(user)
+}
+
+func (m *Mesh) dropLocked(user string) ([]string, bool) {
+	if !slices.Contains(m.members, user) {
+		return nil, false
+	}
+	m.members = slices.DeleteFunc(m.members, func(id string) bool { return id == user })
+	return slices.Clone(m.members), true
+}
+
+func (m *Mesh) othersLocked(user string) []string {
+	return slices.DeleteFunc(slices.Clone(m.members), func(id string) bool { return id == user })
+}
@@ -0,0 +1,78 @@
+package webrtc
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+var sdp = json.RawMessage(`{}`)
+
+func TestRouteToTarget(t *testing.T) {
+	m := NewMesh(0)
+	for _, user := range []string{"a", "b", "c"} {
+		if _, _, err := m.Route(user, &Signal{Type: Join}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, s := range []*Signal{
+		{Type: Offer, Target: "b", Offer: sdp},
+		{Type: Answer, Target: "b", Answer: sdp},
+		{Type: ICE, Target: "b", Candidate: sdp},
+	} {
+		to, changed, err := m.Route("a", s)
+		if err != nil || changed || !slices.Equal(to, []string{"b"}) {
+			t.Errorf("%s went to %v (changed %v, error %v), want only b", s.Type, to, changed, err)
+		}
+	}
+}
+
+func TestRouteRejects(t *testing.T) {
+	m := NewMesh(0)
+	m.Route("a", &Signal{Type: Join})
+	m.Route("b", &Signal{Type: Join})
+	for _, tc := range []struct {
+		sender string
+		signal *Signal
+		want   error
+	}{
+		{"a", &Signal{Type: Offer, Offer: sdp}, ErrNoTarget},
+		{"a", &Signal{Type: Offer, Target: "b"}, ErrBadSignal},
+		{"a", &Signal{Type: ICE, Target: "b", Offer: sdp}, ErrBadSignal},
+		{"c", &Signal{Type: Offer, Target: "b", Offer: sdp}, ErrNotInCall},
+		{"a", &Signal{Type: Offer, Target: "c", Offer: sdp}, ErrUnknownPeer},
+		{"a", &Signal{Type: Offer, Target: "a", Offer: sdp}, ErrUnknownPeer},
+		{"a", &Signal{Type: Roster}, ErrBadSignal},
+	} {
+		if to, _, err := m.Route(tc.sender, tc.signal); !errors.Is(err, tc.want) || to != nil {
+			t.Errorf("%s %+v went to %v with error %v, want %v", tc.sender, tc.signal, to, err, tc.want)
+		}
+	}
+}
+
+func TestJoinAndLeave(t *testing.T) {
+	m := NewMesh(2)
+	if to, changed, _ := m.Route("a", &Signal{Type: Join}); !changed || len(to) != 0 {
+		t.Errorf("first join went to %v, changed %v", to, changed)
+	}
+	if to, changed, _ := m.Route("b", &Signal{Type: Join}); !changed || !slices.Equal(to, []string{"a"}) {
+		t.Errorf("second join went to %v, changed %v", to, changed)
+	}
+	if to, changed, _ := m.Route("b", &Signal{Type: Join}); changed || !slices.Equal(to, []string{"a"}) {
+		t.Errorf("joining again went to %v, changed %v", to, changed)
+	}
+	if _, _, err := m.Route("c", &Signal{Type: Join}); !errors.Is(err, ErrCallFull) {
+		t.Errorf("joining a full call: %v", err)
+	}
+
+	if to, changed, _ := m.Route("a", &Signal{Type: Leave}); !changed || !slices.Equal(to, []string{"b"}) {
+		t.Errorf("leave went to %v, changed %v", to, changed)
+	}
+	if _, changed := m.Drop("a"); changed {
+		t.Error("dropped a member who already left")
+	}
+	if left, changed := m.Drop("b"); !changed || len(left) != 0 {
+		t.Errorf("dropping the last member left %v", left)
+	}
+}
@@ -85,59 +85,59 @@ export default function useGroupCall() {
     const { sender, payload } = msg;
     if (sender === username) return;
 
-    if (payload.target === '_all') {
-      switch (payload.type) {
-        case 'join':
-          if (inCall) {
-            console.log(`Peer ${sender} joined. Creating offer...`);
-            const pc = createPeer(sender);
-            const offer = await pc.createOffer();
-            await pc.setLocalDescription(offer);
-            sendSignal({ type: 'offer', target: sender, offer });
+    switch (payload.type) {
+      case 'join':
+        if (inCall) {
+          console.log(`Peer ${sender} joined. Creating offer...`);
+          const pc = createPeer(sender);
+          const offer = await pc.createOffer();
+          await pc.setLocalDescription(offer);
+          sendSignal({ type: 'offer', target: sender, offer });
+        }
+        break;
+      case 'leave':
+        dispatch({ type: 'REMOVE_PEER', payload: { username: sender } });
+        break;
+      case 'roster':
+        peers.forEach((_, peer) => {
+          if (!payload.members?.includes(peer)) {
+            dispatch({ type: 'REMOVE_PEER', payload: { username: peer } });
           }
-          break;
-        case 'leave':
-          dispatch({ type: 'REMOVE_PEER', payload: { username: sender } });
-          break;
-      }
-      return;
-    }
-
-    if (payload.target === username) {
-      switch (payload.type) {
-        case 'offer':
-          if (payload.offer) {
-            console.log(`Received offer from ${sender}. Creating answer...`);
-            const pc = createPeer(sender);
-            await pc.setRemoteDescription(new RTCSessionDescription(payload.offer));
-            const answer = await pc.createAnswer();
-            await pc.setLocalDescription(answer);
-            sendSignal({ type: 'answer', target: sender, answer });
+        });
+        break;
+      // The server only sends us offers, answers and candidates meant for us
+      case 'offer':
+        if (payload.offer) {
+          console.log(`Received offer from ${sender}. Creating answer...`);
+          const pc = createPeer(sender);
+          await pc.setRemoteDescription(new RTCSessionDescription(payload.offer));
+          const answer = await pc.createAnswer();
+          await pc.setLocalDescription(answer);
+          sendSignal({ type: 'answer', target: sender, answer });
+        }
+        break;
+      case 'answer':
+        if (payload.answer) {
+          console.log(`Received answer from ${sender}.`);
+          const peer = peers.get(sender);
+          if (peer) {
+            await peer.conn.setRemoteDescription(new RTCSessionDescription(payload.answer));
           }
-          break;
-        case 'answer':
-          if (payload.answer) {
-            console.log(`Received answer from ${sender}.`);
-            const peer = peers.get(sender);
-            if (peer) {
-              await peer.conn.setRemoteDescription(new RTCSessionDescription(payload.answer));
+        }
+        break;
+      case 'ice':
+        if (payload.candidate) {
+          console.log(`Received ICE candidate from ${sender}.`);
+          const peer = peers.get(sender);
+          if (peer && peer.conn.remoteDescription) {
+            try {
+              await peer.conn.addIceCandidate(new RTCIceCandidate(payload.candidate));
+            } catch (err) {
+              console.error('Error adding received ICE candidate', err);
             }
           }
-          break;
-        case 'ice':
-          if (payload.candidate) {
-            console.log(`Received ICE candidate from ${sender}.`);
-            const peer = peers.get(sender);
-            if (peer && peer.conn.remoteDescription) {
-              try {
-                await peer.conn.addIceCandidate(new RTCIceCandidate(payload.candidate));
-              } catch (err) {
-                console.error('Error adding received ICE candidate', err);
-              }
-            }
-          }
-          break;
-      }
+        }
+        break;
     }
   }, [username, createPeer, sendSignal, inCall, peers]);
 
@@ -157,7 +157,7 @@ export default function useGroupCall() {
       setIsInCall(true);
       setAudioOn(true);
       setVideoOn(true);
-      sendSignal({ type: 'join', target: '_all' });
+      sendSignal({ type: 'join' });
     } catch (err) {
       console.error('Error getting user media:', err);
     }
@@ -170,7 +170,7 @@ export default function useGroupCall() {
     dispatch({ type: 'CLEAR_PEERS' });
     setLocalStream(null);
     setIsInCall(false);
-    sendSignal({ type: 'leave', target: '_all' });
+    sendSignal({ type: 'leave' });
   };
 
   const toggleAudio = () => {
@@ -9,6 +9,7 @@ export default function useWebSocketGame() {
   const [gameState, setGameState] = useState<BoardGameState>({
     gameName: '',
     players: [],
+    seats: 0,
     turn: 0,
     board: [[]],
     status: 'waiting',
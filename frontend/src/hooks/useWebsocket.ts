@@ -12,6 +12,8 @@ interface WSState {
 
   msgLog: t.DisplayableMsg[];
   clients: Record<string, string>;
+  rooms: t.RoomInfo[];
+  invite: t.InviteRes['payload'] | null;
 
   gameStates: t.IncomingGameState[];
   boardGameState: t.BoardGameState | null;
@@ -24,8 +26,18 @@ interface WSState {
   sendMessage: (msg: t.OutgoingMsg) => void;
 
   sendChat: (message: string) => void;
-  joinRoom: (roomName: string) => void;
+  joinRoom: (
+    roomName: string,
+    settings?: Omit<t.JoinRoomMsg['payload'], 'roomName' | 'seq' | 'resumed'>
+  ) => void;
   leaveRoom: () => void;
+  getRooms: (filter?: t.GetRoomsMsg['payload']) => void;
+  createInvite: (user?: string) => void;
+  moderate: (
+    type: t.ModerateMsg['type'],
+    user: string,
+    opts?: Omit<t.ModerateMsg['payload'], 'user'>
+  ) => void;
 
   setVidSigHandler: (handler: ((msg: t.VidSignalMsg) => void) | null) => void;
   sendVidSignal: (payload: t.VidSignalMsg['payload']) => void;
@@ -36,6 +48,8 @@ interface WSState {
 }
 
 let ws: WebSocket | null = null; //causes problems when defined in store
+let resumeToken = ''; // lets a reconnect go back to the same room
+let lastSeq = -1; // last room broadcast seen
 
 let vidSigHandler: ((msg: t.VidSignalMsg) => void) | null = null; //also causes rerendering issues
 let drawHandler: ((msg: t.DrawPayload) => void) | null = null;
@@ -47,6 +61,8 @@ const useWebSocket = create<WSState>()((set, get) => ({
 
   msgLog: [],
   clients: {},
+  rooms: [],
+  invite: null,
   gameStates: [],
   boardGameState: null,
 
@@ -63,7 +79,11 @@ const useWebSocket = create<WSState>()((set, get) => ({
 
     set({ error: '' });
 
-    ws = new WebSocket(WS_URL);
+    ws = new WebSocket(
+      resumeToken
+        ? `${WS_URL}?resume=${encodeURIComponent(resumeToken)}&seq=${lastSeq}`
+        : WS_URL
+    );
     ws.onopen = () => {
       console.debug('WS connected');
     };
@@ -71,6 +91,7 @@ const useWebSocket = create<WSState>()((set, get) => ({
     ws.onmessage = (e: MessageEvent) => {
       try {
         const msg: t.IncomingMsg = JSON.parse(e.data);
+        if (msg.seq !== undefined) lastSeq = msg.seq;
 
         switch (msg.type) {
           case t.msgChat:
@@ -100,11 +121,33 @@ const useWebSocket = create<WSState>()((set, get) => ({
             break;
 
           case t.msgJoinRoom:
+            if (!msg.payload.resumed) lastSeq = msg.payload.seq ?? -1;
             set({ currentRoom: msg.payload.roomName });
             break;
+          case t.msgSession:
+            resumeToken = msg.payload.token;
+            break;
           case t.msgGetClients:
             set({ clients: msg.payload.clients });
             break;
+          case t.msgGetRooms:
+            set({ rooms: msg.payload.rooms });
+            break;
+          case t.msgInvite:
+            set({ invite: msg.payload });
+            break;
+          case t.msgRoomUpdate: {
+            const { room, name, removed } = msg.payload;
+            set(state => {
+              const rooms = state.rooms.filter(
+                r => r.name !== (room?.name ?? name)
+              );
+              if (room && !removed) rooms.push(room);
+              rooms.sort((a, b) => a.name.localeCompare(b.name));
+              return { rooms };
+            });
+            break;
+          }
           default:
             console.warn('Unknown message', msg);
         }
@@ -131,8 +174,17 @@ const useWebSocket = create<WSState>()((set, get) => ({
       ws.close(1000, 'Client wants to leave');
       ws = null;
     }
+    resumeToken = '';
+    lastSeq = -1;
     console.debug('WS disconnect');
-    set({ currentRoom: '', msgLog: [], clients: {}, error: '' });
+    set({
+      currentRoom: '',
+      msgLog: [],
+      clients: {},
+      rooms: [],
+      invite: null,
+      error: '',
+    });
   },
   sendMessage: (msg: t.OutgoingMsg) => {
     if (!ws || get().getStatus() !== 'connected') {
@@ -154,11 +206,11 @@ const useWebSocket = create<WSState>()((set, get) => ({
     };
     get().sendMessage(msg);
   },
-  joinRoom: (roomName: string) => {
+  joinRoom: (roomName, settings) => {
     const msg: t.JoinRoomMsg = {
       type: t.msgJoinRoom,
       sender: '',
-      payload: { roomName },
+      payload: { roomName, ...settings },
     };
     get().sendMessage(msg);
   },
@@ -166,6 +218,18 @@ const useWebSocket = create<WSState>()((set, get) => ({
     const msg: t.LeaveRoomMsg = { type: t.msgLeaveRoom };
     get().sendMessage(msg);
   },
+  getRooms: filter => {
+    const msg: t.GetRoomsMsg = { type: t.msgGetRooms, payload: filter };
+    get().sendMessage(msg);
+  },
+  createInvite: user => {
+    const msg: t.InviteMsg = { type: t.msgInvite, payload: { user } };
+    get().sendMessage(msg);
+  },
+  moderate: (type, user, opts) => {
+    const msg: t.ModerateMsg = { type, payload: { user, ...opts } };
+    get().sendMessage(msg);
+  },
   setVidSigHandler: handler => {
     vidSigHandler = handler;
   },
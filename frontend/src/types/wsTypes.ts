@@ -10,6 +10,16 @@ export const msgJoinRoom = 'join_room' as const;
 export const msgLeaveRoom = 'leave_room' as const;
 export const msgGetRooms = 'get_rooms' as const;
 export const msgGetClients = 'get_clients' as const;
+export const msgQueue = 'queue' as const;
+export const msgSession = 'session' as const;
+export const msgRoomUpdate = 'room_update' as const;
+export const msgInvite = 'invite' as const;
+
+// For a room's host
+export const msgKick = 'kick' as const;
+export const msgBan = 'ban' as const;
+export const msgMute = 'mute' as const;
+export const msgTransferOwner = 'transfer_owner' as const;
 
 export interface ErrorMsg {
   type: typeof msgError;
@@ -32,6 +42,100 @@ export interface JoinRoomMsg {
   sender: string;
   payload: {
     roomName: string;
+    seq?: number; // of the room's last broadcast, from the server
+    resumed?: boolean; // missed broadcasts follow
+    password?: string; // to enter, or to set on a room the join opens
+    invite?: string; // token from an InviteRes
+    // Only used when the join opens the room
+    title?: string;
+    visibility?: RoomVisibility;
+    capacity?: number; // 0 for any number
+    inviteOnly?: boolean;
+  };
+}
+
+export type RoomVisibility = 'public' | 'unlisted';
+export type RoomAccess = 'open' | 'password' | 'invite';
+
+// Only the room's host may ask
+export interface InviteMsg {
+  type: typeof msgInvite;
+  payload?: {
+    user?: string; // to invite only them
+  };
+}
+
+export interface ModerateMsg {
+  type:
+    | typeof msgKick
+    | typeof msgBan
+    | typeof msgMute
+    | typeof msgTransferOwner;
+  payload: {
+    user: string;
+    persist?: boolean; // ban: also once the room has closed
+    undo?: boolean; // lift a ban or mute
+  };
+}
+
+export interface InviteRes {
+  type: typeof msgInvite;
+  sender: '_server';
+  payload: {
+    roomName: string;
+    user: string;
+    token: string;
+  };
+}
+
+export interface RoomInfo {
+  name: string;
+  title: string;
+  visibility: RoomVisibility;
+  access: RoomAccess;
+  host?: string;
+  capacity: number; // 0 for any number
+  users: number;
+  gameName?: GameName;
+  status?: BoardGameState['status'];
+  players: number;
+}
+
+export interface GetRoomsMsg {
+  type: typeof msgGetRooms;
+  payload?: {
+    gameName?: GameName;
+    status?: BoardGameState['status'];
+    search?: string; // in titles
+    open?: boolean; // only rooms with space left
+  };
+}
+
+export interface GetRoomsRes {
+  type: typeof msgGetRooms;
+  sender: '_server';
+  payload: {
+    rooms: RoomInfo[];
+  };
+}
+
+// Pushed to the lobby when a public room opens, changes or closes
+export interface RoomUpdateRes {
+  type: typeof msgRoomUpdate;
+  sender: '_server';
+  payload: {
+    room?: RoomInfo;
+    name?: string;
+    removed?: boolean;
+  };
+}
+
+export interface SessionRes {
+  type: typeof msgSession;
+  sender: '_server';
+  payload: {
+    token: string; // pass back as ?resume= to pick up where we left off
+    resumed: boolean;
   };
 }
 
@@ -40,6 +144,27 @@ export interface LeaveRoomMsg {
   // Payload: {};
 }
 
+export interface QueueMsg {
+  type: typeof msgQueue;
+  payload: {
+    action: 'join' | 'cancel';
+    gameName?: string;
+    timeControl?: TimeControl;
+    minRating?: number;
+    maxRating?: number;
+  };
+}
+
+export interface QueueRes {
+  type: typeof msgQueue;
+  sender: '_server';
+  payload: {
+    status: 'queued' | 'matched' | 'cancelled';
+    gameName?: string;
+    roomName?: string;
+  };
+}
+
 export interface ChatMsg {
   type: typeof msgChat;
   sender: string;
@@ -52,8 +177,9 @@ export interface VidSignalMsg {
   type: typeof msgVidSignal;
   sender: string;
   payload: {
-    type: 'join' | 'leave' | 'offer' | 'answer' | 'ice';
-    target: string;
+    type: 'join' | 'leave' | 'offer' | 'answer' | 'ice' | 'roster';
+    target?: string; // the one peer an offer, answer or ice goes to
+    members?: string[]; // who is in the call, on roster from the server
     offer?: RTCSessionDescriptionInit;
     answer?: RTCSessionDescriptionInit;
     candidate?: RTCIceCandidateInit;
@@ -66,9 +192,15 @@ export interface GetClientRes {
   payload: {
     roomName: string;
     clients: Record<string, string>;
+    ratings?: Record<string, Record<string, RatingInfo>>;
   };
 }
 
+export interface RatingInfo {
+  rating: number;
+  provisional?: boolean;
+}
+
 export interface DrawPayload {
   type: 'draw';
   points: Array<{ x: number; y: number }>;
@@ -88,13 +220,63 @@ export const GAME_NAMES = ['tictactoe', 'connect4', 'chess'] as const;
 export type GameName = typeof GAME_NAMES[number];
 
 export interface BoardGameState {
+  id?: string;
   gameName: GameName | '';
   players: string[];
+  seats: number;
+  teams?: number[];
   turn: number;
   board: number[][];
   status: 'waiting' | 'in_progress' | 'finished' | 'disconnected';
   winner: string;
+  winners?: string[];
+  reason?: string;
+  eliminated?: string[];
   validMoves: GameMove[];
+  spectators?: string[];
+  clock?: ClockState;
+  drawOffer?: string;
+  drawAccepted?: string[];
+  takeback?: string;
+  takebackAccepted?: string[];
+  series?: Series;
+  rematch?: string[];
+  notation?: {
+    fen?: string;
+    pgn?: string;
+  };
+  scoring?: {
+    scores: Record<string, number>;
+    marking?: boolean;
+    done?: string[];
+  };
+}
+
+export interface Series {
+  scores: Record<string, number>;
+  draws: number;
+  games: number;
+}
+
+export interface TimeControl {
+  initial: number;
+  increment: number;
+  perMove: number;
+}
+
+export interface ClockState {
+  remaining?: number[];
+  moveRemaining?: number;
+  running: boolean;
+}
+
+export interface GameOptions {
+  timeControl?: TimeControl;
+  fen?: string;
+  pgn?: string;
+  boardSize?: 9 | 13 | 19;
+  players?: number;
+  teams?: number;
 }
 
 export interface Position {
@@ -105,13 +287,37 @@ export interface Position {
 export interface GameMove {
   from?: Position;
   to: Position;
-  change?: string;
+  change?: string; // promotion, ship direction, or 'resume' to play on from Go marking
+  path?: Position[];
+  pass?: boolean;
+  out?: string;
 }
 
 export interface GamePayload {
-  action: 'get' | 'create' | 'join' | 'leave' | 'move';
+  action:
+    | 'get'
+    | 'create'
+    | 'join'
+    | 'spectate'
+    | 'leave'
+    | 'move'
+    | 'resign'
+    | 'offer_draw'
+    | 'accept_draw'
+    | 'decline_draw'
+    | 'request_takeback'
+    | 'accept_takeback'
+    | 'decline_takeback'
+    | 'rematch'
+    | 'replay'
+    | 'stop_replay';
   gameName?: GameName;
+  options?: GameOptions;
+  bot?: 'easy' | 'medium' | 'hard';
+  gameId?: string;
+  interval?: number;
   move?: GameMove;
+  seat?: number;
 }
 
 export interface OutgoingGameState {
@@ -127,15 +333,21 @@ export interface IncomingGameState {
 }
 
 
-export type IncomingMsg =
+export type IncomingMsg = (
   | ChatMsg
   | VidSignalMsg
   | IncomingGameState
   | JoinRoomMsg
+  | SessionRes
   | GetClientRes
+  | GetRoomsRes
+  | RoomUpdateRes
+  | InviteRes
+  | QueueRes
   | ErrorMsg
   | StatusMsg
   | RawDrawMsg
+) & { seq?: number }; // set on room broadcasts
 
 export type OutgoingMsg =
   | ChatMsg
@@ -143,6 +355,10 @@ export type OutgoingMsg =
   | OutgoingGameState
   | JoinRoomMsg
   | LeaveRoomMsg
+  | GetRoomsMsg
+  | InviteMsg
+  | ModerateMsg
+  | QueueMsg
   | RawDrawMsg
 
 export type DisplayableMsg =
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS games (
+    id UUID PRIMARY KEY,
+    game_name VARCHAR(50) NOT NULL,
+    players VARCHAR(255)[] NOT NULL,
+    winner VARCHAR(255),
+    winners VARCHAR(255)[],
+    reason VARCHAR(50),
+    options JSONB,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ended_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_games_players ON games USING GIN (players);
+CREATE INDEX IF NOT EXISTS idx_games_ended_at ON games (ended_at DESC);
+
+CREATE TABLE IF NOT EXISTS game_moves (
+    game_id UUID NOT NULL REFERENCES games (id) ON DELETE CASCADE,
+    ply INTEGER NOT NULL,
+    player VARCHAR(255) NOT NULL,
+    move JSONB NOT NULL,
+    played_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (game_id, ply)
+);
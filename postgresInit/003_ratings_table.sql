@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS ratings (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    game_name VARCHAR(50) NOT NULL,
+    rating INTEGER NOT NULL DEFAULT 1500,
+    games INTEGER NOT NULL DEFAULT 0,
+    wins INTEGER NOT NULL DEFAULT 0,
+    losses INTEGER NOT NULL DEFAULT 0,
+    draws INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, game_name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ratings_game_rating ON ratings (game_name, rating DESC);
+
+CREATE TRIGGER update_ratings_updated_at
+BEFORE UPDATE ON ratings
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();